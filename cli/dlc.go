@@ -0,0 +1,442 @@
+package cli
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/arelate/southern_light/vangogh_integration"
+	"github.com/arelate/theo/data"
+	"github.com/boggydigital/kevlar"
+	"github.com/boggydigital/nod"
+	"github.com/boggydigital/redux"
+)
+
+func DlcHandler(u *url.URL) error {
+
+	q := u.Query()
+
+	id := q.Get(vangogh_integration.UrlIdParameter)
+
+	operatingSystem := vangogh_integration.AnyOperatingSystem
+	if q.Has(vangogh_integration.UrlOperatingSystemParameter) {
+		operatingSystem = vangogh_integration.ParseOperatingSystem(q.Get(vangogh_integration.UrlOperatingSystemParameter))
+	}
+
+	var langCode string
+	if q.Has(vangogh_integration.UrlLanguageCodeParameter) {
+		langCode = q.Get(vangogh_integration.UrlLanguageCodeParameter)
+	}
+
+	ii := &InstallInfo{
+		OperatingSystem: operatingSystem,
+		LangCode:        langCode,
+		Origin:          data.UnknownOrigin,
+		verbose:         q.Has(vangogh_integration.UrlVerboseParameter),
+		force:           q.Has(vangogh_integration.UrlForceParameter),
+	}
+
+	if q.Has(vangogh_integration.UrlEpicGamesParameter) {
+		ii.Origin = data.EpicGamesOrigin
+	}
+
+	list := q.Has(vangogh_integration.UrlListParameter)
+
+	var add []string
+	if q.Has(data.UrlAddParameter) {
+		add = strings.Split(q.Get(data.UrlAddParameter), ",")
+	}
+
+	var remove []string
+	if q.Has(vangogh_integration.UrlRemoveParameter) {
+		remove = strings.Split(q.Get(vangogh_integration.UrlRemoveParameter), ",")
+	}
+
+	return Dlc(id, ii, list, add, remove)
+}
+
+func Dlc(id string, request *InstallInfo, list bool, add, remove []string) error {
+
+	da := nod.Begin("managing DLCs for %s...", id)
+	defer da.Done()
+
+	if !list && len(add) == 0 && len(remove) == 0 {
+		return errors.New("dlc requires action: list, add, remove")
+	}
+
+	rdx, err := redux.NewWriter(vangogh_integration.AbsReduxDir(), data.AllProperties()...)
+	if err != nil {
+		return err
+	}
+
+	installedInfo, err := matchInstalledInfo(id, request, rdx)
+	if err != nil {
+		return err
+	}
+
+	installedInfo.verbose = request.verbose
+	installedInfo.force = request.force
+
+	// getting origin data updates install info with the latest origin values (e.g. version),
+	// so a copy is used to preserve installed values
+	dataInstallInfo := *installedInfo
+
+	originData, err := originGetData(id, &dataInstallInfo, rdx, true)
+	if err != nil {
+		return err
+	}
+
+	if len(add) > 0 {
+		if err = originAddDlcs(id, installedInfo, add, originData, rdx); err != nil {
+			return err
+		}
+	}
+
+	if len(remove) > 0 {
+		if err = originRemoveDlcs(id, installedInfo, remove, rdx); err != nil {
+			return err
+		}
+	}
+
+	if len(add) > 0 || len(remove) > 0 {
+		if err = pinInstallInfo(id, installedInfo, rdx); err != nil {
+			return err
+		}
+	}
+
+	if list {
+		if err = listDlcs(id, installedInfo, originData); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func listDlcs(id string, ii *InstallInfo, originData *data.OriginData) error {
+
+	lda := nod.Begin("listing DLCs for %s...", id)
+	defer lda.Done()
+
+	availableDlcs, err := originAvailableDlcs(ii, originData)
+	if err != nil {
+		return err
+	}
+
+	if len(availableDlcs) == 0 {
+		lda.EndWithResult("found nothing")
+		return nil
+	}
+
+	summary := make(map[string][]string)
+
+	for dlcId, dlcTitle := range availableDlcs {
+		dlcLine := fmt.Sprintf("%s (%s)", dlcTitle, dlcId)
+		switch slices.Contains(ii.DownloadableContent, dlcId) {
+		case true:
+			summary[dlcLine] = []string{"installed"}
+		case false:
+			summary[dlcLine] = []string{"not installed"}
+		}
+	}
+
+	lda.EndWithSummary(fmt.Sprintf("found %d DLC(s):", len(availableDlcs)), summary)
+
+	return nil
+}
+
+func originAvailableDlcs(ii *InstallInfo, originData *data.OriginData) (map[string]string, error) {
+	switch ii.Origin {
+	case data.VangoghOrigin:
+		return vangoghAvailableDlcs(ii, originData)
+	case data.EpicGamesOrigin:
+		return egsAvailableDlcs(ii, originData)
+	default:
+		return nil, errors.New("DLCs are not supported for " + ii.Origin.String())
+	}
+}
+
+func originAddDlcs(id string, ii *InstallInfo, dlcIds []string, originData *data.OriginData, rdx redux.Writeable) error {
+	switch ii.Origin {
+	case data.VangoghOrigin:
+		return vangoghAddDlcs(id, ii, dlcIds, originData, rdx)
+	case data.EpicGamesOrigin:
+		return egsAddDlcs(id, ii, dlcIds, originData)
+	default:
+		return errors.New("DLCs are not supported for " + ii.Origin.String())
+	}
+}
+
+func originRemoveDlcs(id string, ii *InstallInfo, dlcIds []string, rdx redux.Writeable) error {
+
+	for _, dlcId := range dlcIds {
+		if !slices.Contains(ii.DownloadableContent, dlcId) {
+			return errors.New("DLC is not installed: " + dlcId)
+		}
+	}
+
+	switch ii.Origin {
+	case data.VangoghOrigin:
+		for _, dlcId := range dlcIds {
+			if err := vangoghUninstallDlc(id, dlcId, ii, rdx); err != nil {
+				return err
+			}
+		}
+	case data.EpicGamesOrigin:
+		for _, dlcId := range dlcIds {
+			// uninstall gets origin data that would update install info values, so a copy is used
+			dlcInstallInfo := *ii
+			if err := originUninstall(dlcId, &dlcInstallInfo, rdx); err != nil {
+				return err
+			}
+		}
+	default:
+		return errors.New("DLCs are not supported for " + ii.Origin.String())
+	}
+
+	ii.DownloadableContent = slices.DeleteFunc(ii.DownloadableContent, func(dlcId string) bool {
+		return slices.Contains(dlcIds, dlcId)
+	})
+
+	// from now on only selected DLCs should be installed for this product
+	ii.NoDlcs = true
+
+	return nil
+}
+
+// vangoghDlcId uses DLC product slug from the manual-url as an id,
+// e.g. /downloads/the_witcher_3_wild_hunt_hearts_of_stone/en1installer0.
+// GOG details don't provide DLC product ids, while the slug identifies the DLC product
+// and doesn't change between installer versions (unlike the installer part of the manual-url)
+func vangoghDlcId(manualUrl string) string {
+	if parts := strings.Split(strings.Trim(manualUrl, "/"), "/"); len(parts) > 1 {
+		return parts[len(parts)-2]
+	}
+	return path.Base(manualUrl)
+}
+
+func vangoghDownloadsListDlcIds(downloadsList vangogh_integration.DownloadsList) []string {
+	dlcIds := make([]string, 0)
+	for _, dl := range downloadsList {
+		if dl.DownloadType != vangogh_integration.DLC {
+			continue
+		}
+		if dlcId := vangoghDlcId(dl.ManualUrl); !slices.Contains(dlcIds, dlcId) {
+			dlcIds = append(dlcIds, dlcId)
+		}
+	}
+	return dlcIds
+}
+
+func vangoghDownloadsListDlc(downloadsList vangogh_integration.DownloadsList, dlcId string) vangogh_integration.DownloadsList {
+	dlcList := make(vangogh_integration.DownloadsList, 0)
+	for _, dl := range downloadsList {
+		if dl.DownloadType == vangogh_integration.DLC && vangoghDlcId(dl.ManualUrl) == dlcId {
+			dlcList = append(dlcList, dl)
+		}
+	}
+	return dlcList
+}
+
+func vangoghDlcDownloadsList(ii *InstallInfo, originData *data.OriginData) (vangogh_integration.DownloadsList, error) {
+
+	downloadsList, err := vangogh_integration.FromDetails(originData.GogDetails)
+	if err != nil {
+		return nil, err
+	}
+
+	return downloadsList.
+		FilterOperatingSystems(ii.OperatingSystem).
+		FilterLangCodes(ii.LangCode).
+		FilterDownloadTypes(vangogh_integration.DLC).
+		FilterPatches(true), nil
+}
+
+func vangoghAvailableDlcs(ii *InstallInfo, originData *data.OriginData) (map[string]string, error) {
+
+	dlcDownloadsList, err := vangoghDlcDownloadsList(ii, originData)
+	if err != nil {
+		return nil, err
+	}
+
+	availableDlcs := make(map[string]string)
+	for _, dl := range dlcDownloadsList {
+		availableDlcs[vangoghDlcId(dl.ManualUrl)] = dl.ProductTitle
+	}
+
+	return availableDlcs, nil
+}
+
+func vangoghAddDlcs(id string, ii *InstallInfo, dlcIds []string, originData *data.OriginData, rdx redux.Writeable) error {
+
+	vada := nod.Begin("adding DLCs for %s...", id)
+	defer vada.Done()
+
+	dlcDownloadsList, err := vangoghDlcDownloadsList(ii, originData)
+	if err != nil {
+		return err
+	}
+
+	var manualUrls []string
+	for _, dlcId := range dlcIds {
+
+		dlcList := vangoghDownloadsListDlc(dlcDownloadsList, dlcId)
+		if len(dlcList) == 0 {
+			return errors.New("DLC not found: " + dlcId)
+		}
+
+		for _, dl := range dlcList {
+			manualUrls = append(manualUrls, dl.ManualUrl)
+		}
+	}
+
+	// DLC installation must not depend on product DLC preference and only process DLC downloads
+	dlcInstallInfo := *ii
+	dlcInstallInfo.NoDlcs = false
+	dlcInstallInfo.downloadTypes = []vangogh_integration.DownloadType{vangogh_integration.DLC}
+
+	if err = Download(id, &dlcInstallInfo, originData, manualUrls...); err != nil {
+		return err
+	}
+
+	if !dlcInstallInfo.NoValidation {
+		if err = Validate(id, &dlcInstallInfo, manualUrls...); err != nil {
+			return err
+		}
+	}
+
+	if err = vangoghUnpackPlace(id, &dlcInstallInfo, vangogh_integration.DLC, originData, rdx, manualUrls...); err != nil {
+		return err
+	}
+
+	if !dlcInstallInfo.KeepDownloads {
+		if err = RemoveDownloads(id, &dlcInstallInfo, rdx); err != nil {
+			return err
+		}
+	}
+
+	ii.DownloadableContent = dlcInstallInfo.DownloadableContent
+
+	return nil
+}
+
+func vangoghUninstallDlc(id, dlcId string, ii *InstallInfo, rdx redux.Writeable) error {
+
+	vuda := nod.Begin("uninstalling %s DLC %s...", id, dlcId)
+	defer vuda.Done()
+
+	relDlcInventory, err := readDlcInventory(id, dlcId, ii, rdx)
+	if err != nil {
+		return err
+	}
+
+	if err = removeDlcInventoriedFiles(id, dlcId, ii, rdx); err != nil {
+		return err
+	}
+
+	// only checksums of the files that were removed with the DLC are no longer needed
+	var removedFiles []string
+	if absInstalledDir, err := originOsInstalledPath(id, ii, rdx); err == nil {
+		for _, relFile := range relDlcInventory {
+			if _, err = os.Stat(filepath.Join(absInstalledDir, relFile)); os.IsNotExist(err) {
+				removedFiles = append(removedFiles, relFile)
+			}
+		}
+	} else {
+		return err
+	}
+
+	if err = cutInventoryChecksums(id, ii, rdx, removedFiles...); err != nil {
+		return err
+	}
+
+	return removeDlcInventoryFile(id, dlcId, ii, rdx)
+}
+
+// dlcNamesToIds converts DLC display names recorded in install info by earlier versions
+// to DLC ids, using locally available origin data. Values are left unchanged when
+// that data is not available locally
+func dlcNamesToIds(id string, ii *InstallInfo) (bool, error) {
+
+	if len(ii.DownloadableContent) == 0 {
+		return false, nil
+	}
+
+	var dlcNameIds map[string][]string
+	var err error
+
+	switch ii.Origin {
+	case data.VangoghOrigin:
+		dlcNameIds, err = vangoghLocalDlcNameIds(id)
+	case data.EpicGamesOrigin:
+		dlcNameIds, err = egsLocalDlcNameIds(id, ii.OperatingSystem)
+	default:
+		return false, nil
+	}
+
+	if err != nil {
+		return false, err
+	}
+
+	var converted bool
+	dlcIds := make([]string, 0, len(ii.DownloadableContent))
+
+	for _, dlc := range ii.DownloadableContent {
+		ids := []string{dlc}
+		if nameIds, ok := dlcNameIds[dlc]; ok {
+			ids = nameIds
+			converted = true
+		}
+		for _, dlcId := range ids {
+			if !slices.Contains(dlcIds, dlcId) {
+				dlcIds = append(dlcIds, dlcId)
+			}
+		}
+	}
+
+	ii.DownloadableContent = dlcIds
+
+	return converted, nil
+}
+
+func vangoghLocalDlcNameIds(id string) (map[string][]string, error) {
+
+	kvGogDetails, err := kevlar.New(vangogh_integration.AbsProductTypeDir(vangogh_integration.GogDetails), vangogh_integration.GogDetails.Ext())
+	if err != nil {
+		return nil, err
+	}
+
+	if !kvGogDetails.Has(id) {
+		return nil, nil
+	}
+
+	details, err := vangogh_integration.UnmarshalDetails(id, kvGogDetails)
+	if err != nil {
+		return nil, err
+	}
+
+	downloadsList, err := vangogh_integration.FromDetails(details)
+	if err != nil {
+		return nil, err
+	}
+
+	dlcIds := vangoghDownloadsListDlcIds(downloadsList)
+
+	dlcNameIds := make(map[string][]string)
+	for _, dl := range downloadsList.FilterDownloadTypes(vangogh_integration.DLC) {
+		dlcId := vangoghDlcId(dl.ManualUrl)
+		for _, name := range []string{dl.Name, dl.ProductTitle} {
+			if name == "" || slices.Contains(dlcIds, name) || slices.Contains(dlcNameIds[name], dlcId) {
+				continue
+			}
+			dlcNameIds[name] = append(dlcNameIds[name], dlcId)
+		}
+	}
+
+	return dlcNameIds, nil
+}
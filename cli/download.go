@@ -1,10 +1,10 @@
 package cli
 
 import (
+	"errors"
 	"net/url"
 	"strings"
 
-	"github.com/arelate/southern_light/egs_integration"
 	"github.com/arelate/southern_light/vangogh_integration"
 	"github.com/arelate/theo/data"
 	"github.com/boggydigital/nod"
@@ -35,6 +35,13 @@ func DownloadHandler(u *url.URL) error {
 		force:           q.Has(vangogh_integration.UrlForceParameter),
 	}
 
+	if q.Has(vangogh_integration.UrlDownloadTypeParameter) {
+		var err error
+		if ii.downloadTypes, err = parseDownloadTypes(strings.Split(q.Get(vangogh_integration.UrlDownloadTypeParameter), ",")); err != nil {
+			return err
+		}
+	}
+
 	if q.Has(vangogh_integration.UrlSteamParameter) {
 		ii.Origin = data.SteamOrigin
 	}
@@ -51,6 +58,37 @@ func DownloadHandler(u *url.URL) error {
 	return Download(id, ii, nil, manualUrlFilter...)
 }
 
+// extrasDownloadType is accepted in addition to the download-types values for consistency
+// with extras parameters of other commands
+const extrasDownloadType = "extras"
+
+func DownloadTypes() []string {
+	return append(vangogh_integration.DownloadTypesCloValues(), extrasDownloadType)
+}
+
+// parseDownloadTypes parses download types and, unlike ParseManyDownloadTypes,
+// rejects unknown values instead of treating them as any download type
+func parseDownloadTypes(dtStrings []string) ([]vangogh_integration.DownloadType, error) {
+
+	downloadTypes := make([]vangogh_integration.DownloadType, 0, len(dtStrings))
+
+	for _, dtStr := range dtStrings {
+
+		if dtStr == extrasDownloadType {
+			dtStr = vangogh_integration.Extra.String()
+		}
+
+		dt := vangogh_integration.ParseDownloadType(dtStr)
+		if dt == vangogh_integration.AnyDownloadType && dtStr != vangogh_integration.AnyDownloadType.String() {
+			return nil, errors.New("unknown download type: " + dtStr)
+		}
+
+		downloadTypes = append(downloadTypes, dt)
+	}
+
+	return downloadTypes, nil
+}
+
 func Download(id string,
 	ii *InstallInfo,
 	originData *data.OriginData,
@@ -103,31 +141,9 @@ func originGetData(id string, ii *InstallInfo, rdx redux.Writeable, force bool)
 			return nil, err
 		}
 	case data.EpicGamesOrigin:
-
-		var gameAssetsOs []vangogh_integration.OperatingSystem
-		gameAssetsOs, err = egsGameAssetOperatingSystems(id, force)
-		if err != nil {
-			return nil, err
-		}
-
-		setInstallInfoDefaults(ii, gameAssetsOs)
-
-		var gameAsset *egs_integration.GameAsset
-		if gameAsset, err = egsGetGameAsset(id, ii); err != nil {
+		if err = egsGetOriginData(id, ii, originData, rdx, force, force); err != nil {
 			return nil, err
 		}
-		if originData.CatalogItem, err = egsGetCatalogItem(gameAsset, ii, rdx, force); err != nil {
-			return nil, err
-		}
-
-		// the data items below must be the latest version from the origin when downloading, don't remove force parameter
-		if originData.GameManifest, err = egsGetGameManifest(gameAsset, ii, force); err != nil {
-			return nil, err
-		}
-		if originData.Manifest, err = egsGetManifest(gameAsset.AppName, originData.GameManifest, ii.OperatingSystem, force); err != nil {
-			return nil, err
-		}
-
 	default:
 		return nil, ii.Origin.ErrUnsupportedOrigin()
 	}
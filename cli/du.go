@@ -0,0 +1,309 @@
+package cli
+
+import (
+	"cmp"
+	"fmt"
+	"math"
+	"net/url"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/arelate/southern_light/vangogh_integration"
+	"github.com/arelate/theo/data"
+	"github.com/boggydigital/camino"
+	"github.com/boggydigital/nod"
+	"github.com/boggydigital/redux"
+)
+
+type duSort string
+
+const (
+	duSortTitle     duSort = "title"
+	duSortTotal     duSort = "total"
+	duSortInstalled duSort = "installed"
+	duSortPrefix    duSort = "prefix"
+	duSortDownloads duSort = "downloads"
+	duSortChunks    duSort = "chunks"
+)
+
+// estimated size is only an estimate of downloads, so only large differences are reported
+const duMismatchThreshold = 0.25
+
+type productDiskUsage struct {
+	id             string
+	title          string
+	installInfo    InstallInfo
+	installedBytes int64
+	prefixBytes    int64
+	downloadsBytes int64
+	chunksBytes    int64
+}
+
+func (pdu *productDiskUsage) total() int64 {
+	return pdu.installedBytes + pdu.prefixBytes + pdu.downloadsBytes + pdu.chunksBytes
+}
+
+// estimateMismatch returns relative difference between installed and estimated size,
+// when it exceeds mismatch threshold
+func (pdu *productDiskUsage) estimateMismatch() (float64, bool) {
+
+	estimated := pdu.installInfo.EstimatedBytes
+	if estimated <= 0 || pdu.installedBytes == 0 {
+		return 0, false
+	}
+
+	diff := float64(pdu.installedBytes-estimated) / float64(estimated)
+
+	return diff, math.Abs(diff) > duMismatchThreshold
+}
+
+func (pdu *productDiskUsage) String() string {
+
+	parts := []string{
+		fmt.Sprintf("%s %s (%s %s)", pdu.id, pdu.title, pdu.installInfo.Origin, data.OsLangCode(pdu.installInfo.OperatingSystem, pdu.installInfo.LangCode)),
+		"total: " + vangogh_integration.FormatBytes(pdu.total()),
+		"installed: " + vangogh_integration.FormatBytes(pdu.installedBytes),
+	}
+
+	if pdu.prefixBytes > 0 {
+		parts = append(parts, "prefix: "+vangogh_integration.FormatBytes(pdu.prefixBytes))
+	}
+	if pdu.downloadsBytes > 0 {
+		parts = append(parts, "downloads: "+vangogh_integration.FormatBytes(pdu.downloadsBytes))
+	}
+	if pdu.chunksBytes > 0 {
+		parts = append(parts, "chunks: "+vangogh_integration.FormatBytes(pdu.chunksBytes))
+	}
+
+	if diff, ok := pdu.estimateMismatch(); ok {
+		parts = append(parts, fmt.Sprintf("estimated: %s (%+.0f%%)",
+			vangogh_integration.FormatBytes(pdu.installInfo.EstimatedBytes), diff*100))
+	}
+
+	return strings.Join(parts, "; ")
+}
+
+func DuHandler(u *url.URL) error {
+
+	q := u.Query()
+
+	id := q.Get(vangogh_integration.UrlIdParameter)
+
+	sortBy := duSortTotal
+	if q.Has(vangogh_integration.UrlSortParameter) {
+		sortBy = duSort(q.Get(vangogh_integration.UrlSortParameter))
+	}
+
+	desc := q.Has(vangogh_integration.UrlDescendingParameter)
+
+	return Du(id, sortBy, desc)
+}
+
+func Du(id string, sortBy duSort, desc bool) error {
+
+	dua := nod.Begin("measuring disk usage...")
+	defer dua.Done()
+
+	rdx, err := redux.NewReader(vangogh_integration.AbsReduxDir(), data.AllProperties()...)
+	if err != nil {
+		return err
+	}
+
+	productsUsage, err := productsDiskUsage(id, rdx)
+	if err != nil {
+		return err
+	}
+
+	sortProductsDiskUsage(productsUsage, sortBy, desc)
+
+	summary := make(map[string][]string)
+
+	var productsTotal int64
+	mismatches := 0
+
+	for _, pdu := range productsUsage {
+		productsTotal += pdu.total()
+		summary["products:"] = append(summary["products:"], pdu.String())
+		if _, ok := pdu.estimateMismatch(); ok {
+			mismatches++
+		}
+	}
+
+	if mismatches > 0 {
+		summary["products:"] = append(summary["products:"],
+			fmt.Sprintf("%d product(s) differ from the estimated size by more than %.0f%%", mismatches, duMismatchThreshold*100))
+	}
+
+	// runtimes and backups are shared between products, so they're only reported for all products
+	if id == "" {
+		runtimesBytes, err := duSize(camino.GetRel(vangogh_integration.Runtimes, vangogh_integration.Binaries))
+		if err != nil {
+			return err
+		}
+
+		backupsBytes, err := duSize(camino.GetAbs(vangogh_integration.Backups))
+		if err != nil {
+			return err
+		}
+
+		summary["totals:"] = []string{
+			"products: " + vangogh_integration.FormatBytes(productsTotal),
+			"runtimes: " + vangogh_integration.FormatBytes(runtimesBytes),
+			"backups: " + vangogh_integration.FormatBytes(backupsBytes),
+			"all: " + vangogh_integration.FormatBytes(productsTotal+runtimesBytes+backupsBytes),
+		}
+	}
+
+	if len(summary) == 0 {
+		dua.EndWithResult("found nothing")
+		return nil
+	}
+
+	dua.EndWithSummary("disk usage:", summary)
+
+	return nil
+}
+
+// productsDiskUsage measures actual disk usage of a product, or all installed products
+// when id is not specified
+func productsDiskUsage(id string, rdx redux.Readable) ([]*productDiskUsage, error) {
+
+	pdua := nod.NewProgress(" measuring products...")
+	defer pdua.Done()
+
+	installedInfos, err := allInstalledInfos(rdx)
+	if err != nil {
+		return nil, err
+	}
+
+	ids := make([]string, 0, len(installedInfos))
+	for iid := range installedInfos {
+		if id == "" || iid == id {
+			ids = append(ids, iid)
+		}
+	}
+
+	pdua.TotalInt(len(ids))
+
+	productsUsage := make([]*productDiskUsage, 0, len(ids))
+
+	// downloads and prefixes are shared by all installed operating systems and languages
+	// of a product and are only counted once
+	measuredDirs := make(map[string]any)
+
+	for _, iid := range ids {
+
+		for _, ii := range installedInfos[iid] {
+
+			pdu, err := measureProductDiskUsage(iid, &ii, measuredDirs, rdx)
+			if err != nil {
+				return nil, err
+			}
+
+			productsUsage = append(productsUsage, pdu)
+		}
+
+		pdua.Increment()
+	}
+
+	return productsUsage, nil
+}
+
+func measureProductDiskUsage(id string, ii *InstallInfo, measuredDirs map[string]any, rdx redux.Readable) (*productDiskUsage, error) {
+
+	pdu := &productDiskUsage{
+		id:          id,
+		installInfo: *ii,
+	}
+
+	if title, err := data.GetTitleProperty(id, rdx); err == nil {
+		pdu.title = title
+	}
+
+	absInstalledDir, err := originOsInstalledPath(id, ii, rdx)
+	if err != nil {
+		return nil, err
+	}
+
+	if pdu.installedBytes, err = duSize(absInstalledDir); err != nil {
+		return nil, err
+	}
+
+	// prefixes are only used to run Windows versions on other operating systems
+	if ii.OperatingSystem == vangogh_integration.Windows && vangogh_integration.CurrentOs() != vangogh_integration.Windows {
+
+		var absPrefixDir string
+		if absPrefixDir, err = data.AbsPrefixDir(id, ii.Origin, rdx); err != nil {
+			return nil, err
+		}
+
+		if pdu.prefixBytes, err = duSizeOnce(absPrefixDir, measuredDirs); err != nil {
+			return nil, err
+		}
+	}
+
+	switch ii.Origin {
+	case data.VangoghOrigin:
+		if pdu.downloadsBytes, err = duSizeOnce(filepath.Join(camino.GetAbs(vangogh_integration.Downloads), id), measuredDirs); err != nil {
+			return nil, err
+		}
+	case data.EpicGamesOrigin:
+		if pdu.chunksBytes, err = duSizeOnce(data.AbsChunksDownloadDir(id, ii.OperatingSystem), measuredDirs); err != nil {
+			return nil, err
+		}
+	default:
+		// do nothing
+	}
+
+	return pdu, nil
+}
+
+func sortProductsDiskUsage(productsUsage []*productDiskUsage, sortBy duSort, desc bool) {
+
+	slices.SortStableFunc(productsUsage, func(a, b *productDiskUsage) int {
+
+		var c int
+		switch sortBy {
+		case duSortTitle:
+			c = strings.Compare(strings.ToLower(a.title), strings.ToLower(b.title))
+		case duSortInstalled:
+			c = cmp.Compare(a.installedBytes, b.installedBytes)
+		case duSortPrefix:
+			c = cmp.Compare(a.prefixBytes, b.prefixBytes)
+		case duSortDownloads:
+			c = cmp.Compare(a.downloadsBytes, b.downloadsBytes)
+		case duSortChunks:
+			c = cmp.Compare(a.chunksBytes, b.chunksBytes)
+		default:
+			c = cmp.Compare(a.total(), b.total())
+		}
+
+		if desc {
+			return -c
+		}
+		return c
+	})
+}
+
+// duSize returns size of all files in a directory, missing directories have zero size
+func duSize(absPath string) (int64, error) {
+
+	if _, err := os.Stat(absPath); os.IsNotExist(err) {
+		return 0, nil
+	}
+
+	return dirSize(absPath)
+}
+
+// duSizeOnce returns size of a directory, unless it has been measured already
+func duSizeOnce(absPath string, measuredDirs map[string]any) (int64, error) {
+
+	if _, ok := measuredDirs[absPath]; ok {
+		return 0, nil
+	}
+	measuredDirs[absPath] = nil
+
+	return duSize(absPath)
+}
@@ -7,6 +7,7 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"maps"
 	"net/http"
 	"net/url"
 	"os"
@@ -284,6 +285,36 @@ func egsGetGameAssets(update bool) (map[vangogh_integration.OperatingSystem][]eg
 	return osGameAssets, nil
 }
 
+// egsGetOriginData gets EGS product origin data. Game assets are shared by all EGS products,
+// so updating them is controlled separately from product data
+func egsGetOriginData(appName string, ii *InstallInfo, originData *data.OriginData, rdx redux.Writeable, updateGameAssets, force bool) error {
+
+	gameAssetsOs, err := egsGameAssetOperatingSystems(appName, updateGameAssets)
+	if err != nil {
+		return err
+	}
+
+	setInstallInfoDefaults(ii, gameAssetsOs)
+
+	var gameAsset *egs_integration.GameAsset
+	if gameAsset, err = egsGetGameAsset(appName, ii); err != nil {
+		return err
+	}
+	if originData.CatalogItem, err = egsGetCatalogItem(gameAsset, ii, rdx, force); err != nil {
+		return err
+	}
+
+	// the data items below must be the latest version from the origin when downloading, don't remove force parameter
+	if originData.GameManifest, err = egsGetGameManifest(gameAsset, ii, force); err != nil {
+		return err
+	}
+	if originData.Manifest, err = egsGetManifest(gameAsset.AppName, originData.GameManifest, ii.OperatingSystem, force); err != nil {
+		return err
+	}
+
+	return nil
+}
+
 func availableProductIndex(appName string, availableProducts []vangogh_integration.AvailableProduct) int {
 	for ii, ap := range availableProducts {
 		if ap.Id == appName {
@@ -891,25 +922,46 @@ func egsWriteChunkPart(part *egs_integration.ChunkPart, featureLevel uint32, chu
 
 func egsValidateAssembly(appName string, ii *InstallInfo, originData *data.OriginData, rdx redux.Readable) error {
 
-	evaa := nod.NewProgress("validating assembled files for %s-%s...", appName, ii.Origin)
-	defer evaa.Done()
+	brokenFiles, err := egsCheckAssembledFiles(appName, ii, originData, rdx)
+	if err != nil {
+		return err
+	}
+
+	if len(brokenFiles) > 0 {
+		return fmt.Errorf("failed validation for %d file(s), use validate -epic-games -repair to fix", len(brokenFiles))
+	}
 
-	evaa.Total(uint64(egsManifestSize(originData.Manifest)))
+	return nil
+}
+
+// egsCheckAssembledFiles validates every manifest file and returns
+// broken (missing or modified) files instead of stopping on the first one
+func egsCheckAssembledFiles(appName string, ii *InstallInfo, originData *data.OriginData, rdx redux.Readable) (map[string]string, error) {
+
+	ecafa := nod.NewProgress("validating assembled files for %s-%s...", appName, ii.OperatingSystem)
+	defer ecafa.Done()
+
+	ecafa.Total(uint64(egsManifestSize(originData.Manifest)))
 
 	installedPath, err := originOsInstalledPath(appName, ii, rdx)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
+	brokenFiles := make(map[string]string)
+
 	for _, file := range originData.Manifest.FileList.List {
-		if err = egsValidateAssembledFile(installedPath, &file); err != nil {
-			return err
+		if err = egsValidateAssembledFile(installedPath, &file); os.IsNotExist(err) {
+			brokenFiles[file.Filename] = installedFileMissing
+		} else if err != nil {
+			brokenFiles[file.Filename] = installedFileModified
+			nod.Log("%s", err.Error())
 		}
 
-		evaa.Progress(file.Size)
+		ecafa.Progress(file.Size)
 	}
 
-	return nil
+	return brokenFiles, nil
 }
 
 func egsValidateAssembledFile(installedDir string, assembledFile *egs_integration.File) error {
@@ -922,6 +974,7 @@ func egsValidateAssembledFile(installedDir string, assembledFile *egs_integratio
 	if err != nil {
 		return err
 	}
+	defer inputFile.Close()
 
 	shaSum := sha1.New()
 
@@ -939,6 +992,145 @@ func egsValidateAssembledFile(installedDir string, assembledFile *egs_integratio
 	return nil
 }
 
+func egsValidateInstalled(appName string, ii *InstallInfo, rdx redux.Writeable, repair bool) error {
+
+	evia := nod.Begin(" checking %s-%s files...", appName, ii.OperatingSystem)
+	defer evia.Done()
+
+	// getting origin data updates install info with the latest origin values (e.g. version),
+	// so a copy is used to preserve installed values
+	dataInstallInfo := *ii
+
+	originData, err := originGetData(appName, &dataInstallInfo, rdx, false)
+	if err != nil {
+		return err
+	}
+
+	if manifestVersion := egsManifestVersion(originData.Manifest); manifestVersion != ii.Version {
+		msg := fmt.Sprintf("installed version %s doesn't match manifest version %s", ii.Version, manifestVersion)
+		if repair && !ii.force {
+			return errors.New(msg + ", update the product or use -force to repair with the manifest version")
+		}
+		nod.Log("%s", msg)
+	}
+
+	brokenFiles, err := egsCheckAssembledFiles(appName, ii, originData, rdx)
+	if err != nil {
+		return err
+	}
+
+	if len(brokenFiles) == 0 {
+		evia.EndWithResult("all %d files are valid", len(originData.Manifest.FileList.List))
+		return nil
+	}
+
+	summary := make(map[string][]string)
+	for _, filename := range slices.Sorted(maps.Keys(brokenFiles)) {
+		heading := brokenFiles[filename] + ":"
+		summary[heading] = append(summary[heading], filename)
+	}
+
+	evia.EndWithSummary(fmt.Sprintf("%d of %d files are not valid:", len(brokenFiles), len(originData.Manifest.FileList.List)), summary)
+
+	if repair {
+		return egsRepairFiles(appName, ii, originData, brokenFiles, rdx)
+	}
+
+	return nil
+}
+
+// egsRepairChunks returns the minimal set of chunks required to reassemble specified files
+func egsRepairChunks(originData *data.OriginData, filenames []string) []*egs_integration.Chunk {
+
+	chunks := make([]*egs_integration.Chunk, 0)
+
+	for _, file := range originData.Manifest.FileList.List {
+		if !slices.Contains(filenames, file.Filename) {
+			continue
+		}
+		for _, part := range file.Parts {
+			if part.Chunk == nil || slices.Contains(chunks, part.Chunk) {
+				continue
+			}
+			chunks = append(chunks, part.Chunk)
+		}
+	}
+
+	return chunks
+}
+
+func egsRepairFiles(appName string, ii *InstallInfo, originData *data.OriginData, brokenFiles map[string]string, rdx redux.Writeable) error {
+
+	erfa := nod.Begin("repairing %d file(s) for %s-%s...", len(brokenFiles), appName, ii.OperatingSystem)
+	defer erfa.Done()
+
+	start := time.Now()
+
+	filenames := slices.Sorted(maps.Keys(brokenFiles))
+
+	chunks := egsRepairChunks(originData, filenames)
+
+	nod.Log("repairing %s requires %d of %d chunks", appName, len(chunks), len(originData.Manifest.ChunkList.Chunks))
+
+	if err := egsDownloadChunks(appName, ii, originData, chunks...); err != nil {
+		return err
+	}
+
+	if !ii.NoValidation {
+		if err := egsValidateChunks(appName, ii, originData, chunks...); err != nil {
+			return err
+		}
+	}
+
+	installedPath, err := originOsInstalledPath(appName, ii, rdx)
+	if err != nil {
+		return err
+	}
+
+	absChunksDownloadsDir := data.AbsChunksDownloadDir(appName, ii.OperatingSystem)
+	featureLevel := originData.Manifest.Metadata.FeatureLevel
+
+	summary := make(map[string][]string)
+	repairedFiles := 0
+
+	for _, file := range originData.Manifest.FileList.List {
+
+		if _, ok := brokenFiles[file.Filename]; !ok {
+			continue
+		}
+
+		fileResult := brokenFiles[file.Filename] + ", "
+		if err = egsAssembleFile(&file, featureLevel, absChunksDownloadsDir, installedPath); err != nil {
+			fileResult += "repair failed: " + err.Error()
+		} else if err = egsValidateAssembledFile(installedPath, &file); err != nil {
+			fileResult += "repaired file is not valid"
+		} else {
+			fileResult += "repaired"
+			repairedFiles++
+		}
+
+		summary[file.Filename] = []string{fileResult}
+	}
+
+	if !ii.KeepDownloads {
+		if err = egsRemoveChunks(appName, ii.OperatingSystem, originData); err != nil {
+			return err
+		}
+	}
+
+	if err = egsChmodLauncherExe(appName, ii, originData, rdx); err != nil {
+		return err
+	}
+
+	if err = recordHistoryEvent(appName, historyEventRepair, ii, start, rdx); err != nil {
+		return err
+	}
+
+	erfa.EndWithSummary(fmt.Sprintf("repaired %d of %d file(s):", repairedFiles, len(brokenFiles)), summary)
+
+	return nil
+}
+
 func egsChmodLauncherExe(id string, ii *InstallInfo, originData *data.OriginData, rdx redux.Readable) error {
 
 	switch ii.OperatingSystem {
@@ -984,6 +1176,16 @@ func egsManifestSize(manifest *egs_integration.Manifest) int64 {
 	return totalEstimatedBytes
 }
 
+func egsChunksSize(chunks []*egs_integration.Chunk) uint64 {
+	var totalBytes uint64
+
+	for _, chunk := range chunks {
+		totalBytes += chunk.FileSize
+	}
+
+	return totalBytes
+}
+
 func egsAssembleValidateChunks(appName string, ii *InstallInfo, originData *data.OriginData, rdx redux.Readable) error {
 
 	egsAppsDir := camino.GetRel(vangogh_integration.EgsApps, vangogh_integration.InstalledApps)
@@ -1038,6 +1240,57 @@ func egsCatalogItemDlcGameAssets(osGameAssets map[vangogh_integration.OperatingS
 	return dlcGameAssets, nil
 }
 
+func egsLocalDlcNameIds(appName string, operatingSystem vangogh_integration.OperatingSystem) (map[string][]string, error) {
+
+	if !slices.Contains(egs_integration.SupportedOperatingSystems, operatingSystem) {
+		return nil, nil
+	}
+
+	gameAssets, err := egsReadLocalGameAssets(operatingSystem)
+	if err != nil {
+		return nil, err
+	}
+
+	var catalogItemId string
+	for _, gameAsset := range gameAssets {
+		if gameAsset.AppName == appName {
+			catalogItemId = gameAsset.CatalogItemId
+			break
+		}
+	}
+
+	kvCatalogItems, err := kevlar.New(vangogh_integration.AbsProductTypeDir(vangogh_integration.EgsCatalogItems), kevlar.JsonExt)
+	if err != nil {
+		return nil, err
+	}
+
+	if catalogItemId == "" || !kvCatalogItems.Has(catalogItemId) {
+		return nil, nil
+	}
+
+	catalogItem, err := egsReadLocalCatalogItem(catalogItemId, kvCatalogItems)
+	if err != nil {
+		return nil, err
+	}
+
+	osGameAssets := map[vangogh_integration.OperatingSystem][]egs_integration.GameAsset{operatingSystem: gameAssets}
+
+	dlcGameAssets, err := egsCatalogItemDlcGameAssets(osGameAssets, operatingSystem, catalogItem, false)
+	if err != nil {
+		return nil, err
+	}
+
+	dlcNameIds := make(map[string][]string)
+	for dlcAppName, dlcTitle := range dlcGameAssets {
+		if _, ok := dlcGameAssets[dlcTitle]; ok {
+			continue
+		}
+		dlcNameIds[dlcTitle] = append(dlcNameIds[dlcTitle], dlcAppName)
+	}
+
+	return dlcNameIds, nil
+}
+
 func egsInstallDownloadableContent(ii *InstallInfo, catalogItem *egs_integration.CatalogItem) error {
 
 	if len(catalogItem.DlcItemList) == 0 {
@@ -1057,12 +1310,58 @@ func egsInstallDownloadableContent(ii *InstallInfo, catalogItem *egs_integration
 		return err
 	}
 
-	for dlcAppName, dlcTitle := range dlcGameAssets {
+	for dlcAppName := range dlcGameAssets {
 		if err = Install(dlcAppName, ii); err != nil {
 			return err
 		}
 
-		ii.DownloadableContent = append(ii.DownloadableContent, dlcTitle)
+		if !slices.Contains(ii.DownloadableContent, dlcAppName) {
+			ii.DownloadableContent = append(ii.DownloadableContent, dlcAppName)
+		}
+	}
+
+	return nil
+}
+
+func egsAvailableDlcs(ii *InstallInfo, originData *data.OriginData) (map[string]string, error) {
+
+	osGameAssets, err := egsGetGameAssets(ii.force)
+	if err != nil {
+		return nil, err
+	}
+
+	return egsCatalogItemDlcGameAssets(osGameAssets, ii.OperatingSystem, originData.CatalogItem, ii.force)
+}
+
+func egsAddDlcs(appName string, ii *InstallInfo, dlcAppNames []string, originData *data.OriginData) error {
+
+	eada := nod.Begin("adding DLCs for %s...", appName)
+	defer eada.Done()
+
+	availableDlcs, err := egsAvailableDlcs(ii, originData)
+	if err != nil {
+		return err
+	}
+
+	for _, dlcAppName := range dlcAppNames {
+		if _, ok := availableDlcs[dlcAppName]; !ok {
+			return errors.New("DLC not found: " + dlcAppName)
+		}
+	}
+
+	for _, dlcAppName := range dlcAppNames {
+
+		// installation updates install info values with DLC origin data, so a copy is used
+		dlcInstallInfo := *ii
+		dlcInstallInfo.force = true
+
+		if err = Install(dlcAppName, &dlcInstallInfo); err != nil {
+			return err
+		}
+
+		if !slices.Contains(ii.DownloadableContent, dlcAppName) {
+			ii.DownloadableContent = append(ii.DownloadableContent, dlcAppName)
+		}
 	}
 
 	return nil
@@ -1098,16 +1397,21 @@ func egsUninstallDlcs(appName string, ii *InstallInfo, rdx redux.Writeable) erro
 	return nil
 }
 
-func egsValidateChunks(appName string, ii *InstallInfo, originData *data.OriginData) error {
+func egsValidateChunks(appName string, ii *InstallInfo, originData *data.OriginData, chunks ...*egs_integration.Chunk) error {
 
 	evca := nod.NewProgress("validating EGS chunks for %s-%s...", appName, ii.OperatingSystem)
 	defer evca.Done()
 
-	evca.Total(uint64(egsManifestSize(originData.Manifest)))
+	if len(chunks) == 0 {
+		chunks = originData.Manifest.ChunkList.Chunks
+		evca.Total(uint64(egsManifestSize(originData.Manifest)))
+	} else {
+		evca.Total(egsChunksSize(chunks))
+	}
 
 	absChunksDownloadsDir := data.AbsChunksDownloadDir(appName, ii.OperatingSystem)
 
-	for _, chunk := range originData.Manifest.ChunkList.Chunks {
+	for _, chunk := range chunks {
 
 		chunkPath := chunk.Path(originData.Manifest.Metadata.FeatureLevel)
 
@@ -1201,18 +1505,32 @@ func egsResetConnection() error {
 	return nil
 }
 
-func egsDownloadChunks(appName string, ii *InstallInfo, originData *data.OriginData) error {
+func egsDownloadChunks(appName string, ii *InstallInfo, originData *data.OriginData, chunks ...*egs_integration.Chunk) error {
 
 	edca := nod.NewProgress("downloading EGS chunks...")
 	edca.Done()
 
 	downloadsDir := camino.GetAbs(vangogh_integration.Downloads)
 
-	if err := originHasFreeSpace(appName, downloadsDir, ii, originData); err != nil {
-		return err
-	}
+	if len(chunks) == 0 {
+
+		if err := originHasFreeSpace(appName, downloadsDir, ii, originData); err != nil {
+			return err
+		}
+
+		chunks = originData.Manifest.ChunkList.Chunks
+		edca.Total(uint64(egsManifestSize(originData.Manifest)))
 
-	edca.Total(uint64(egsManifestSize(originData.Manifest)))
+	} else {
+
+		if ok, err := hasFreeSpaceForBytes(downloadsDir, int64(egsChunksSize(chunks))); err != nil {
+			return err
+		} else if !ok && !ii.force {
+			return fmt.Errorf("not enough space for %s chunks", appName)
+		}
+
+		edca.Total(egsChunksSize(chunks))
+	}
 
 	cdnUrls, err := originData.GameManifest.Urls()
 	if err != nil {
@@ -1236,7 +1554,7 @@ func egsDownloadChunks(appName string, ii *InstallInfo, originData *data.OriginD
 	originalPath := strings.TrimSuffix(cdnUrl.Path, filepath.Base(cdnUrl.Path))
 	cdnUrl.RawQuery = ""
 
-	for _, chunk := range originData.Manifest.ChunkList.Chunks {
+	for _, chunk := range chunks {
 
 		chunkPath := chunk.Path(originData.Manifest.Metadata.FeatureLevel)
 		cdnUrl.Path = path.Join(originalPath, chunkPath)
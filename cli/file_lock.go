@@ -0,0 +1,35 @@
+//go:build !windows
+
+package cli
+
+import (
+	"errors"
+	"os"
+	"syscall"
+)
+
+// tryLockFile takes an exclusive advisory lock on the file without waiting. The lock is held
+// until the file is closed or the process exits, so terminated processes never leave it behind
+func tryLockFile(file *os.File) (bool, error) {
+
+	if err := syscall.Flock(int(file.Fd()), syscall.LOCK_EX|syscall.LOCK_NB); errors.Is(err, syscall.EWOULDBLOCK) {
+		return false, nil
+	} else if err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
+
+// fileLocked checks whether another open file holds the lock on the file
+func fileLocked(absPath string) bool {
+
+	file, err := os.Open(absPath)
+	if err != nil {
+		return false
+	}
+	defer file.Close()
+
+	locked, err := tryLockFile(file)
+	return err == nil && !locked
+}
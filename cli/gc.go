@@ -0,0 +1,510 @@
+package cli
+
+import (
+	"fmt"
+	"maps"
+	"net/url"
+	"os"
+	"path/filepath"
+	"slices"
+	"time"
+
+	"github.com/arelate/southern_light/egs_integration"
+	"github.com/arelate/southern_light/steam_vdf"
+	"github.com/arelate/southern_light/vangogh_integration"
+	"github.com/arelate/southern_light/wine_integration"
+	"github.com/arelate/theo/data"
+	"github.com/boggydigital/camino"
+	"github.com/boggydigital/kevlar"
+	"github.com/boggydigital/nod"
+	"github.com/boggydigital/redux"
+)
+
+type gcCategory string
+
+const (
+	gcCategoryTemp       gcCategory = "temp"
+	gcCategoryChunks     gcCategory = "chunks"
+	gcCategoryUmuConfigs gcCategory = "umu-configs"
+	gcCategoryRuntimes   gcCategory = "runtimes"
+	gcCategoryMetadata   gcCategory = "metadata"
+	gcCategoryPrefixes   gcCategory = "prefixes"
+)
+
+var allGcCategories = []gcCategory{
+	gcCategoryTemp,
+	gcCategoryChunks,
+	gcCategoryUmuConfigs,
+	gcCategoryRuntimes,
+	gcCategoryMetadata,
+	gcCategoryPrefixes,
+}
+
+// prefixes contain user data (e.g. saves, settings) and are only collected when requested explicitly
+var defaultGcCategories = slices.DeleteFunc(slices.Clone(allGcCategories), func(gcc gcCategory) bool {
+	return gcc == gcCategoryPrefixes
+})
+
+// temp dirs modified recently might belong to an installation running in another theo process
+const gcTempMinAge = 24 * time.Hour
+
+func (gcc gcCategory) String() string {
+	switch gcc {
+	case gcCategoryTemp:
+		return "leftover unpacked files"
+	case gcCategoryChunks:
+		return "orphaned EGS chunks"
+	case gcCategoryUmuConfigs:
+		return "old umu-launcher configs"
+	case gcCategoryRuntimes:
+		return "old runtimes versions"
+	case gcCategoryMetadata:
+		return "uninstalled products metadata"
+	case gcCategoryPrefixes:
+		return "uninstalled products prefixes"
+	default:
+		return string(gcc)
+	}
+}
+
+// gcItem is a single piece of stale data. Most items are removed from the filesystem,
+// kevlar values are cut to keep kevlar log consistent
+type gcItem struct {
+	absPath string
+	size    int64
+	kv      kevlar.KeyValues
+	key     string
+}
+
+func (gci *gcItem) remove() error {
+	if gci.kv != nil {
+		return gci.kv.Cut(gci.key)
+	}
+	return os.RemoveAll(gci.absPath)
+}
+
+func GcHandler(u *url.URL) error {
+
+	q := u.Query()
+
+	categories := make([]gcCategory, 0)
+	for _, gcc := range allGcCategories {
+		if q.Has(string(gcc)) {
+			categories = append(categories, gcc)
+		}
+	}
+
+	if len(categories) == 0 {
+		categories = defaultGcCategories
+	}
+
+	apply := q.Has(data.UrlApplyParameter)
+
+	return Gc(categories, apply)
+}
+
+func Gc(categories []gcCategory, apply bool) error {
+
+	gca := nod.Begin("collecting stale theo data...")
+	defer gca.Done()
+
+	rdx, err := redux.NewReader(vangogh_integration.AbsReduxDir(), data.AllProperties()...)
+	if err != nil {
+		return err
+	}
+
+	installedInfos, err := allInstalledInfos(rdx)
+	if err != nil {
+		return err
+	}
+
+	categoriesItems := make(map[gcCategory][]*gcItem)
+
+	for _, gcc := range categories {
+
+		var items []*gcItem
+
+		switch gcc {
+		case gcCategoryTemp:
+			items, err = gcTempItems()
+		case gcCategoryChunks:
+			items, err = gcChunksItems(installedInfos, rdx)
+		case gcCategoryUmuConfigs:
+			items, err = gcUmuConfigsItems(rdx)
+		case gcCategoryRuntimes:
+			items, err = gcRuntimesItems(rdx)
+		case gcCategoryMetadata:
+			items, err = gcMetadataItems(installedInfos)
+		case gcCategoryPrefixes:
+			items, err = gcPrefixesItems(installedInfos, rdx)
+		}
+
+		if err != nil {
+			return err
+		}
+
+		if len(items) > 0 {
+			categoriesItems[gcc] = items
+		}
+	}
+
+	if len(categoriesItems) == 0 {
+		gca.EndWithResult("nothing to collect")
+		return nil
+	}
+
+	summary := make(map[string][]string)
+
+	var totalSize int64
+	for gcc, items := range categoriesItems {
+
+		var categorySize int64
+		for _, item := range items {
+			categorySize += item.size
+		}
+		totalSize += categorySize
+
+		heading := fmt.Sprintf("%s (%s):", gcc, vangogh_integration.FormatBytes(categorySize))
+		for _, item := range items {
+			summary[heading] = append(summary[heading], fmt.Sprintf("%s (%s)", item.absPath, vangogh_integration.FormatBytes(item.size)))
+		}
+	}
+
+	if !apply {
+		gca.EndWithSummary(fmt.Sprintf("%s can be reclaimed, use -apply to remove:", vangogh_integration.FormatBytes(totalSize)), summary)
+		return nil
+	}
+
+	if err = gcRemoveItems(categoriesItems); err != nil {
+		return err
+	}
+
+	gca.EndWithSummary(fmt.Sprintf("reclaimed %s:", vangogh_integration.FormatBytes(totalSize)), summary)
+
+	return nil
+}
+
+func gcRemoveItems(categoriesItems map[gcCategory][]*gcItem) error {
+
+	gria := nod.NewProgress("removing stale data...")
+	defer gria.Done()
+
+	var total int
+	for _, items := range categoriesItems {
+		total += len(items)
+	}
+
+	gria.TotalInt(total)
+
+	for _, items := range categoriesItems {
+		for _, item := range items {
+			if err := item.remove(); err != nil {
+				return err
+			}
+			gria.Increment()
+		}
+	}
+
+	return nil
+}
+
+// allInstalledInfos returns install info for all installed products, keyed by product id
+func allInstalledInfos(rdx redux.Readable) (map[string][]InstallInfo, error) {
+
+	if err := rdx.MustHave(data.InstallInfoProperty); err != nil {
+		return nil, err
+	}
+
+	installedInfos := make(map[string][]InstallInfo)
+
+	for id := range rdx.Keys(data.InstallInfoProperty) {
+		if lines, ok := rdx.GetAllValues(data.InstallInfoProperty, id); ok {
+			iis, err := unmarshalInstalledInfoLines(lines...)
+			if err != nil {
+				return nil, err
+			}
+			for ii := range iis {
+				if _, err = dlcNamesToIds(id, &iis[ii]); err != nil {
+					return nil, err
+				}
+			}
+			installedInfos[id] = iis
+		}
+	}
+
+	return installedInfos, nil
+}
+
+func newGcItem(absPath string) (*gcItem, error) {
+
+	size, err := dirSize(absPath)
+	if err != nil {
+		return nil, err
+	}
+
+	return &gcItem{absPath: absPath, size: size}, nil
+}
+
+// gcDirItems returns items for all dir entries, except the ones that should be kept
+func gcDirItems(absDir string, keep func(name string) bool) ([]*gcItem, error) {
+
+	entries, err := os.ReadDir(absDir)
+	if os.IsNotExist(err) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	items := make([]*gcItem, 0)
+
+	for _, entry := range entries {
+
+		if keep != nil && keep(entry.Name()) {
+			continue
+		}
+
+		item, err := newGcItem(filepath.Join(absDir, entry.Name()))
+		if err != nil {
+			return nil, err
+		}
+
+		items = append(items, item)
+	}
+
+	return items, nil
+}
+
+func gcTempItems() ([]*gcItem, error) {
+
+	absTempDir := camino.GetRel(vangogh_integration.Temp, vangogh_integration.Downloads)
+
+	var modTimeErr error
+
+	// unpacked installers are removed after every installation,
+	// so anything left in the temp dir long enough is a leftover of an interrupted operation
+	items, err := gcDirItems(absTempDir, func(name string) bool {
+		modTime, err := dirModTime(filepath.Join(absTempDir, name))
+		if err != nil {
+			modTimeErr = err
+			return true
+		}
+		return time.Since(modTime) < gcTempMinAge
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return items, modTimeErr
+}
+
+func gcChunksItems(installedInfos map[string][]InstallInfo, rdx redux.Readable) ([]*gcItem, error) {
+
+	if err := rdx.MustHave(vangogh_integration.EgsTitleProperty); err != nil {
+		return nil, err
+	}
+
+	items := make([]*gcItem, 0)
+
+	// EGS chunks download dir is specific to app name and operating system,
+	// so all possible dirs for known EGS apps are checked
+	for appName := range rdx.Keys(vangogh_integration.EgsTitleProperty) {
+		for _, operatingSystem := range []vangogh_integration.OperatingSystem{vangogh_integration.Windows, vangogh_integration.MacOS} {
+
+			if egsChunksInUse(appName, operatingSystem, installedInfos) {
+				continue
+			}
+
+			absChunksDir := data.AbsChunksDownloadDir(appName, operatingSystem)
+			if _, err := os.Stat(absChunksDir); os.IsNotExist(err) {
+				continue
+			}
+
+			item, err := newGcItem(absChunksDir)
+			if err != nil {
+				return nil, err
+			}
+
+			items = append(items, item)
+		}
+	}
+
+	return items, nil
+}
+
+// egsChunksInUse checks if chunks belong to an installed EGS app or one of its installed DLCs
+func egsChunksInUse(appName string, operatingSystem vangogh_integration.OperatingSystem, installedInfos map[string][]InstallInfo) bool {
+	for id, iis := range installedInfos {
+		for _, ii := range iis {
+			if ii.Origin != data.EpicGamesOrigin || ii.OperatingSystem != operatingSystem {
+				continue
+			}
+			if id == appName || slices.Contains(ii.DownloadableContent, appName) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func gcUmuConfigsItems(rdx redux.Readable) ([]*gcItem, error) {
+
+	// umu-launcher is not set up on every operating system
+	if !rdx.HasKey(data.WineBinariesVersionsProperty, wine_integration.UmuLauncher) {
+		return nil, nil
+	}
+
+	latestUmuConfigsDir, err := getLatestUmuConfigsDir(rdx)
+	if err != nil {
+		return nil, err
+	}
+
+	umuConfigsDir, latestVersion := filepath.Split(latestUmuConfigsDir)
+
+	return gcDirItems(umuConfigsDir, func(name string) bool {
+		return name == latestVersion
+	})
+}
+
+func gcRuntimesItems(rdx redux.Readable) ([]*gcItem, error) {
+
+	if err := rdx.MustHave(data.WineBinariesVersionsProperty); err != nil {
+		return nil, err
+	}
+
+	runtimesDir := camino.GetRel(vangogh_integration.Runtimes, vangogh_integration.Binaries)
+
+	items := make([]*gcItem, 0)
+
+	// only runtimes with pinned versions are checked, other runtimes (e.g. SteamCMD)
+	// are not versioned and are always kept
+	for title := range rdx.Keys(data.WineBinariesVersionsProperty) {
+
+		latestVersion, ok := rdx.GetLastVal(data.WineBinariesVersionsProperty, title)
+		if !ok || latestVersion == "" {
+			continue
+		}
+
+		runtimeItems, err := gcDirItems(filepath.Join(runtimesDir, camino.Sanitize(title)), func(name string) bool {
+			return name == latestVersion
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		items = append(items, runtimeItems...)
+	}
+
+	return items, nil
+}
+
+func gcMetadataItems(installedInfos map[string][]InstallInfo) ([]*gcItem, error) {
+
+	// metadata is keyed by product id, EGS manifests are keyed by app name and operating system
+	keepKeys := make([]string, 0)
+	for id, iis := range installedInfos {
+		keepKeys = append(keepKeys, id)
+		for _, ii := range iis {
+			keepKeys = append(keepKeys, fmt.Sprintf("%s-%s", id, ii.OperatingSystem))
+			for _, dlcId := range ii.DownloadableContent {
+				keepKeys = append(keepKeys, dlcId, fmt.Sprintf("%s-%s", dlcId, ii.OperatingSystem))
+			}
+		}
+	}
+
+	// kept downloads are validated and installed with checksums and filenames metadata
+	absDownloadsDir := camino.GetAbs(vangogh_integration.Downloads)
+	if entries, err := os.ReadDir(absDownloadsDir); err == nil {
+		for _, entry := range entries {
+			if entry.IsDir() {
+				keepKeys = append(keepKeys, entry.Name())
+			}
+		}
+	} else if !os.IsNotExist(err) {
+		return nil, err
+	}
+
+	metadataDirsExts := map[string]string{
+		data.AbsValidationCacheDir(): kevlar.JsonExt,
+		vangogh_integration.AbsProductTypeDir(vangogh_integration.SteamAppInfo):     steam_vdf.Ext,
+		vangogh_integration.AbsProductTypeDir(vangogh_integration.EgsGameManifests): kevlar.JsonExt,
+		vangogh_integration.AbsProductTypeDir(vangogh_integration.EgsManifests):     egs_integration.ManifestExt,
+	}
+
+	for _, pt := range []vangogh_integration.ProductType{
+		vangogh_integration.GogDetails,
+		vangogh_integration.GogApiProducts,
+		vangogh_integration.GogChecksums,
+		vangogh_integration.GogFilenames,
+	} {
+		metadataDirsExts[vangogh_integration.AbsProductTypeDir(pt)] = pt.Ext()
+	}
+
+	items := make([]*gcItem, 0)
+
+	for _, absDir := range slices.Sorted(maps.Keys(metadataDirsExts)) {
+
+		if _, err := os.Stat(absDir); os.IsNotExist(err) {
+			continue
+		}
+
+		ext := metadataDirsExts[absDir]
+
+		kv, err := kevlar.New(absDir, ext)
+		if err != nil {
+			return nil, err
+		}
+
+		for key := range kv.Keys() {
+
+			if slices.Contains(keepKeys, key) {
+				continue
+			}
+
+			absPath := filepath.Join(absDir, key+ext)
+
+			var size int64
+			if fi, err := os.Stat(absPath); err == nil {
+				size = fi.Size()
+			}
+
+			items = append(items, &gcItem{absPath: absPath, size: size, kv: kv, key: key})
+		}
+	}
+
+	return items, nil
+}
+
+func gcPrefixesItems(installedInfos map[string][]InstallInfo, rdx redux.Readable) ([]*gcItem, error) {
+
+	installedPrefixes := make([]string, 0)
+	for id, iis := range installedInfos {
+		for _, ii := range iis {
+			absPrefixDir, err := data.AbsPrefixDir(id, ii.Origin, rdx)
+			if err != nil {
+				return nil, err
+			}
+			installedPrefixes = append(installedPrefixes, absPrefixDir)
+		}
+	}
+
+	items := make([]*gcItem, 0)
+
+	for _, prefixesDir := range []camino.RelDir{
+		vangogh_integration.GogPrefixes,
+		vangogh_integration.SteamPrefixes,
+		vangogh_integration.EgsPrefixes,
+	} {
+		absPrefixesDir := camino.GetRel(prefixesDir, vangogh_integration.Prefixes)
+
+		prefixItems, err := gcDirItems(absPrefixesDir, func(name string) bool {
+			return slices.Contains(installedPrefixes, filepath.Join(absPrefixesDir, name))
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		items = append(items, prefixItems...)
+	}
+
+	return items, nil
+}
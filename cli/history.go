@@ -0,0 +1,190 @@
+package cli
+
+import (
+	"bytes"
+	"encoding/json/v2"
+	"fmt"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/arelate/southern_light/vangogh_integration"
+	"github.com/arelate/theo/data"
+	"github.com/boggydigital/nod"
+	"github.com/boggydigital/redux"
+)
+
+type historyEvent string
+
+const (
+	historyEventInstall   historyEvent = "install"
+	historyEventUpdate    historyEvent = "update"
+	historyEventUninstall historyEvent = "uninstall"
+	historyEventRepair    historyEvent = "repair"
+	historyEventRollback  historyEvent = "rollback"
+)
+
+type historyEntry struct {
+	Event           historyEvent                        `json:"event"`
+	Date            string                              `json:"date"`
+	Version         string                              `json:"version,omitempty"`
+	Origin          data.Origin                         `json:"origin"`
+	OperatingSystem vangogh_integration.OperatingSystem `json:"os"`
+	LangCode        string                              `json:"lang-code"`
+	EstimatedBytes  int64                               `json:"estimated-bytes"`
+	Duration        string                              `json:"duration"`
+	id              string                              // won't be serialized
+}
+
+func (he *historyEntry) String() string {
+
+	date := he.Date
+	if dt, err := time.Parse(time.RFC3339, he.Date); err == nil {
+		date = dt.Local().Format(time.DateTime)
+	}
+
+	parts := []string{date, string(he.Event), he.Origin.String(), data.OsLangCode(he.OperatingSystem, he.LangCode)}
+	if he.Version != "" {
+		parts = append(parts, "version: "+he.Version)
+	}
+	if he.EstimatedBytes > 0 {
+		parts = append(parts, "size: "+vangogh_integration.FormatBytes(he.EstimatedBytes))
+	}
+	if he.Duration != "" {
+		parts = append(parts, "took: "+he.Duration)
+	}
+
+	return strings.Join(parts, "; ")
+}
+
+func HistoryHandler(u *url.URL) error {
+
+	q := u.Query()
+
+	id := q.Get(vangogh_integration.UrlIdParameter)
+	all := q.Has(vangogh_integration.UrlAllParameter)
+
+	return History(id, all)
+}
+
+func History(id string, all bool) error {
+
+	ha := nod.Begin("showing history...")
+	defer ha.Done()
+
+	if id == "" && !all {
+		ha.EndWithResult("history requires id or all parameter")
+		return nil
+	}
+
+	rdx, err := redux.NewReader(vangogh_integration.AbsReduxDir(), data.AllProperties()...)
+	if err != nil {
+		return err
+	}
+
+	var ids []string
+	switch all {
+	case true:
+		ids = slices.Collect(rdx.Keys(data.HistoryProperty))
+	default:
+		ids = []string{id}
+	}
+
+	entries := make([]*historyEntry, 0)
+	for _, hid := range ids {
+		var idEntries []*historyEntry
+		if idEntries, err = readHistory(hid, rdx); err != nil {
+			return err
+		}
+		entries = append(entries, idEntries...)
+	}
+
+	if len(entries) == 0 {
+		ha.EndWithResult("found nothing")
+		return nil
+	}
+
+	// dates are stored in the same UTC RFC3339 format, so they can be sorted as strings
+	slices.SortStableFunc(entries, func(a, b *historyEntry) int {
+		return strings.Compare(a.Date, b.Date)
+	})
+
+	summary := make(map[string][]string)
+
+	switch all {
+	case true:
+		heading := "timeline:"
+		for _, he := range entries {
+			idTitle := he.id
+			if title, err := data.GetTitleProperty(he.id, rdx); err == nil {
+				idTitle += " " + title
+			}
+			summary[heading] = append(summary[heading], idTitle+"; "+he.String())
+		}
+	default:
+		heading := id
+		if title, err := data.GetTitleProperty(id, rdx); err == nil {
+			heading += " " + title
+		}
+		for _, he := range entries {
+			summary[heading] = append(summary[heading], he.String())
+		}
+	}
+
+	ha.EndWithSummary(fmt.Sprintf("found %d event(s):", len(entries)), summary)
+
+	return nil
+}
+
+func readHistory(id string, rdx redux.Readable) ([]*historyEntry, error) {
+
+	if err := rdx.MustHave(data.HistoryProperty); err != nil {
+		return nil, err
+	}
+
+	values, ok := rdx.GetAllValues(data.HistoryProperty, id)
+	if !ok {
+		return nil, nil
+	}
+
+	entries := make([]*historyEntry, 0, len(values))
+
+	for _, value := range values {
+		var he historyEntry
+		if err := json.UnmarshalRead(strings.NewReader(value), &he); err != nil {
+			return nil, err
+		}
+		he.id = id
+		entries = append(entries, &he)
+	}
+
+	return entries, nil
+}
+
+// recordHistoryEvent appends an event to the product history. History is append-only,
+// so unlike install-date it's preserved across reinstalls and updates
+func recordHistoryEvent(id string, event historyEvent, ii *InstallInfo, start time.Time, rdx redux.Writeable) error {
+
+	if err := rdx.MustHave(data.HistoryProperty); err != nil {
+		return err
+	}
+
+	he := &historyEntry{
+		Event:           event,
+		Date:            time.Now().UTC().Format(time.RFC3339),
+		Version:         ii.Version,
+		Origin:          ii.Origin,
+		OperatingSystem: ii.OperatingSystem,
+		LangCode:        ii.LangCode,
+		EstimatedBytes:  ii.EstimatedBytes,
+		Duration:        time.Since(start).Round(time.Second).String(),
+	}
+
+	buf := bytes.NewBuffer(nil)
+	if err := json.MarshalWrite(buf, he); err != nil {
+		return err
+	}
+
+	return rdx.AddValues(data.HistoryProperty, id, buf.String())
+}
@@ -65,6 +65,8 @@ func Install(id string, ii *InstallInfo) error {
 	ia := nod.Begin("installing %s...", id)
 	defer ia.Done()
 
+	start := time.Now()
+
 	rdx, err := redux.NewWriter(vangogh_integration.AbsReduxDir(), data.AllProperties()...)
 	if err != nil {
 		return err
@@ -150,7 +152,12 @@ func Install(id string, ii *InstallInfo) error {
 		return err
 	}
 
-	return nil
+	event := historyEventInstall
+	if ii.updating {
+		event = historyEventUpdate
+	}
+
+	return recordHistoryEvent(id, event, ii, start, rdx)
 }
 
 func originPinInstallInfo(id string, ii *InstallInfo, originData *data.OriginData, rdx redux.Writeable) error {
@@ -274,7 +281,8 @@ func originPostInstall(id string, ii *InstallInfo, originData *data.OriginData,
 
 func originInstallDownloadableContent(id string, ii *InstallInfo, originData *data.OriginData, rdx redux.Writeable) error {
 
-	if ii.NoDlcs {
+	// DLCs selected with the dlc command are preserved even when not installing all DLCs
+	if ii.NoDlcs && len(ii.DownloadableContent) == 0 {
 		return nil
 	}
 
@@ -283,8 +291,14 @@ func originInstallDownloadableContent(id string, ii *InstallInfo, originData *da
 
 	switch ii.Origin {
 	case data.VangoghOrigin:
+		if ii.NoDlcs {
+			return vangoghAddDlcs(id, ii, ii.DownloadableContent, originData, rdx)
+		}
 		return vangoghUnpackPlace(id, ii, vangogh_integration.DLC, originData, rdx)
 	case data.EpicGamesOrigin:
+		if ii.NoDlcs {
+			return egsAddDlcs(id, ii, ii.DownloadableContent, originData)
+		}
 		if err := egsInstallDownloadableContent(ii, originData.CatalogItem); err != nil {
 			return err
 		}
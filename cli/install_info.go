@@ -40,6 +40,8 @@ type InstallInfo struct {
 	Env                    []string                            `json:"env"`
 	verbose                bool                                // won't be serialized
 	force                  bool                                // won't be serialized
+	updating               bool                                // won't be serialized
+	downloadTypes          []vangogh_integration.DownloadType  // won't be serialized
 }
 
 func (ii *InstallInfo) reduceOriginData(id string, originData *data.OriginData) error {
@@ -130,6 +132,16 @@ func (ii *InstallInfo) reduceOriginData(id string, originData *data.OriginData)
 	return nil
 }
 
+// includesDownloadType defaults to installers and DLCs when no download types were requested,
+// extras are only included when requested explicitly
+func (ii *InstallInfo) includesDownloadType(dt vangogh_integration.DownloadType) bool {
+	if len(ii.downloadTypes) == 0 {
+		return dt == vangogh_integration.Installer || dt == vangogh_integration.DLC
+	}
+	return slices.Contains(ii.downloadTypes, vangogh_integration.AnyDownloadType) ||
+		slices.Contains(ii.downloadTypes, dt)
+}
+
 func (ii *InstallInfo) Matches(another *InstallInfo) bool {
 
 	var matchesOs, matchesLangCode, matchesOrigin bool
@@ -271,7 +283,19 @@ func matchInstalledInfo(id string, request *InstallInfo, rdx redux.Readable) (*I
 	case 0:
 		return nil, ErrInstallInfoNotFound
 	case 1:
-		return &matchedInstalledInfo[0], nil
+		ii := &matchedInstalledInfo[0]
+		// earlier versions recorded DLC display names, convert them to ids once
+		// and persist the result when possible
+		converted, err := dlcNamesToIds(id, ii)
+		if err != nil {
+			return nil, err
+		}
+		if rdxw, ok := rdx.(redux.Writeable); ok && converted {
+			if err = pinInstallInfo(id, ii, rdxw); err != nil {
+				return nil, err
+			}
+		}
+		return ii, nil
 	default:
 		return nil, ErrInstallInfoTooMany
 	}
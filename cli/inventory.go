@@ -86,7 +86,22 @@ func readInventory(id string, ii *InstallInfo, rdx redux.Readable) ([]string, er
 		return nil, err
 	}
 
-	if _, err = os.Stat(absInventoryFilename); os.IsNotExist(err) {
+	return readInventoryFile(absInventoryFilename)
+}
+
+func readDlcInventory(id, dlcId string, ii *InstallInfo, rdx redux.Readable) ([]string, error) {
+
+	absDlcInventoryFilename, err := data.AbsDlcInventoryFilename(id, dlcId, ii.LangCode, ii.OperatingSystem, rdx)
+	if err != nil {
+		return nil, err
+	}
+
+	return readInventoryFile(absDlcInventoryFilename)
+}
+
+func readInventoryFile(absInventoryFilename string) ([]string, error) {
+
+	if _, err := os.Stat(absInventoryFilename); os.IsNotExist(err) {
 		return nil, nil
 	}
 
@@ -94,6 +109,7 @@ func readInventory(id string, ii *InstallInfo, rdx redux.Readable) ([]string, er
 	if err != nil {
 		return nil, err
 	}
+	defer inventoryFile.Close()
 
 	var relFiles []string
 	if err = json.UnmarshalRead(inventoryFile, &relFiles); err != nil {
@@ -110,31 +126,34 @@ func appendInventory(id, langCode string, operatingSystem vangogh_integration.Op
 		return err
 	}
 
-	absInventoryDir, _ := filepath.Split(absInventoryFilename)
+	return appendInventoryFile(absInventoryFilename, inventory...)
+}
 
-	pathDir, _ := filepath.Split(absInventoryDir)
-	if _, err = os.Stat(pathDir); os.IsNotExist(err) {
-		if err = os.MkdirAll(pathDir, camino.DefaultFileMode); err != nil {
-			return err
-		}
+func appendDlcInventory(id, dlcId string, ii *InstallInfo, rdx redux.Readable, inventory ...string) error {
+
+	absDlcInventoryFilename, err := data.AbsDlcInventoryFilename(id, dlcId, ii.LangCode, ii.OperatingSystem, rdx)
+	if err != nil {
+		return err
 	}
 
-	var existingInventory []string
+	return appendInventoryFile(absDlcInventoryFilename, inventory...)
+}
 
-	if _, err = os.Stat(absInventoryFilename); err == nil {
-		var inventoryFile *os.File
-		inventoryFile, err = os.Open(absInventoryFilename)
-		if err != nil {
-			return err
-		}
+func appendInventoryFile(absInventoryFilename string, inventory ...string) error {
 
-		err = json.UnmarshalRead(inventoryFile, &existingInventory)
-		inventoryFile.Close()
-		if err != nil {
+	absInventoryDir, _ := filepath.Split(absInventoryFilename)
+
+	if _, err := os.Stat(absInventoryDir); os.IsNotExist(err) {
+		if err = os.MkdirAll(absInventoryDir, camino.DefaultFileMode); err != nil {
 			return err
 		}
 	}
 
+	existingInventory, err := readInventoryFile(absInventoryFilename)
+	if err != nil {
+		return err
+	}
+
 	existingInventory = append(existingInventory, inventory...)
 
 	inventoryFile, err := os.Create(absInventoryFilename)
@@ -166,22 +185,63 @@ func removeInventoriedFiles(id string, ii *InstallInfo, rdx redux.Readable) erro
 		return err
 	}
 
-	for _, rif := range relInventory {
-		absRif := filepath.Join(absInstalledPath, rif)
-		if _, err = os.Stat(absRif); os.IsNotExist(err) {
+	return removeRelFiles(absInstalledPath, relInventory)
+}
+
+func removeDlcInventoriedFiles(id, dlcId string, ii *InstallInfo, rdx redux.Readable) error {
+
+	rdifa := nod.Begin(" removing inventoried files for %s DLC %s...", id, dlcId)
+	defer rdifa.Done()
+
+	absInstalledPath, err := originOsInstalledPath(id, ii, rdx)
+	if err != nil {
+		return err
+	}
+
+	if _, err = os.Stat(absInstalledPath); os.IsNotExist(err) {
+		rdifa.EndWithResult("not present")
+		return nil
+	}
+
+	relDlcInventory, err := readDlcInventory(id, dlcId, ii, rdx)
+	if err != nil {
+		return err
+	}
+
+	// DLC installers might overwrite main product files, those need to be preserved
+	relInventory, err := readInventory(id, ii, rdx)
+	if err != nil {
+		return err
+	}
+
+	relDlcFiles := make([]string, 0, len(relDlcInventory))
+	for _, rdf := range relDlcInventory {
+		if !slices.Contains(relInventory, rdf) {
+			relDlcFiles = append(relDlcFiles, rdf)
+		}
+	}
+
+	return removeRelFiles(absInstalledPath, relDlcFiles)
+}
+
+func removeRelFiles(absInstalledPath string, relFiles []string) error {
+
+	for _, rf := range relFiles {
+		absRf := filepath.Join(absInstalledPath, rf)
+		if _, err := os.Stat(absRf); os.IsNotExist(err) {
 			continue
 		}
-		if err = os.Remove(absRif); err != nil {
+		if err := os.Remove(absRf); err != nil {
 			return err
 		}
 	}
 
-	relFiles, err := relWalkDir(absInstalledPath)
+	remainingFiles, err := relWalkDir(absInstalledPath)
 	if err != nil {
 		return err
 	}
 
-	if len(relFiles) == 0 {
+	if len(remainingFiles) == 0 {
 		if err = os.RemoveAll(absInstalledPath); err != nil {
 			return err
 		}
@@ -197,11 +257,24 @@ func removeInventoryFile(id string, ii *InstallInfo, rdx redux.Readable) error {
 		return err
 	}
 
-	if _, err = os.Stat(absInventoryFilename); err == nil {
-		if err = os.Remove(absInventoryFilename); err != nil {
+	return removeFileIfExists(absInventoryFilename)
+}
+
+func removeDlcInventoryFile(id, dlcId string, ii *InstallInfo, rdx redux.Readable) error {
+
+	absDlcInventoryFilename, err := data.AbsDlcInventoryFilename(id, dlcId, ii.LangCode, ii.OperatingSystem, rdx)
+	if err != nil {
+		return err
+	}
+
+	return removeFileIfExists(absDlcInventoryFilename)
+}
+
+func removeFileIfExists(absFilename string) error {
+	if _, err := os.Stat(absFilename); err == nil {
+		if err = os.Remove(absFilename); err != nil {
 			return err
 		}
 	}
-
 	return nil
 }
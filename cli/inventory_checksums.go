@@ -0,0 +1,213 @@
+package cli
+
+import (
+	"crypto/sha256"
+	"encoding/json/v2"
+	"fmt"
+	"io"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+
+	"github.com/arelate/southern_light/vangogh_integration"
+	"github.com/arelate/theo/data"
+	"github.com/boggydigital/camino"
+	"github.com/boggydigital/nod"
+	"github.com/boggydigital/redux"
+)
+
+type inventoryChecksum struct {
+	Size   int64  `json:"size"`
+	Sha256 string `json:"sha256"`
+}
+
+func fileSha256(absFilePath string) (string, error) {
+
+	file, err := os.Open(absFilePath)
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+
+	h := sha256.New()
+	if _, err = io.Copy(h, file); err != nil {
+		return "", err
+	}
+
+	return fmt.Sprintf("%x", h.Sum(nil)), nil
+}
+
+func newInventoryChecksum(absFilePath string) (*inventoryChecksum, error) {
+
+	stat, err := os.Stat(absFilePath)
+	if err != nil {
+		return nil, err
+	}
+
+	sum, err := fileSha256(absFilePath)
+	if err != nil {
+		return nil, err
+	}
+
+	return &inventoryChecksum{Size: stat.Size(), Sha256: sum}, nil
+}
+
+func readInventoryChecksums(id string, ii *InstallInfo, rdx redux.Readable) (map[string]inventoryChecksum, error) {
+
+	absChecksumsFilename, err := data.AbsInventoryChecksumsFilename(id, ii.LangCode, ii.OperatingSystem, rdx)
+	if err != nil {
+		return nil, err
+	}
+
+	checksums := make(map[string]inventoryChecksum)
+
+	if _, err = os.Stat(absChecksumsFilename); os.IsNotExist(err) {
+		return checksums, nil
+	}
+
+	checksumsFile, err := os.Open(absChecksumsFilename)
+	if err != nil {
+		return nil, err
+	}
+	defer checksumsFile.Close()
+
+	if err = json.UnmarshalRead(checksumsFile, &checksums); err != nil {
+		return nil, err
+	}
+
+	return checksums, nil
+}
+
+func writeInventoryChecksums(id string, ii *InstallInfo, rdx redux.Readable, checksums map[string]inventoryChecksum) error {
+
+	absChecksumsFilename, err := data.AbsInventoryChecksumsFilename(id, ii.LangCode, ii.OperatingSystem, rdx)
+	if err != nil {
+		return err
+	}
+
+	absChecksumsDir, _ := filepath.Split(absChecksumsFilename)
+	if _, err = os.Stat(absChecksumsDir); os.IsNotExist(err) {
+		if err = os.MkdirAll(absChecksumsDir, camino.DefaultFileMode); err != nil {
+			return err
+		}
+	}
+
+	checksumsFile, err := os.Create(absChecksumsFilename)
+	if err != nil {
+		return err
+	}
+	defer checksumsFile.Close()
+
+	return json.MarshalWrite(checksumsFile, checksums)
+}
+
+func addInventoryChecksums(id string, ii *InstallInfo, rdx redux.Readable, absRootDir string, relFiles ...string) error {
+
+	aica := nod.NewProgress(" recording inventory checksums...")
+	defer aica.Done()
+
+	checksums, err := readInventoryChecksums(id, ii, rdx)
+	if err != nil {
+		return err
+	}
+
+	aica.TotalInt(len(relFiles))
+
+	for _, relFile := range relFiles {
+
+		var checksum *inventoryChecksum
+		if checksum, err = newInventoryChecksum(filepath.Join(absRootDir, relFile)); err == nil {
+			checksums[relFile] = *checksum
+		} else if os.IsNotExist(err) {
+			delete(checksums, relFile)
+		} else {
+			return err
+		}
+
+		aica.Increment()
+	}
+
+	return writeInventoryChecksums(id, ii, rdx, checksums)
+}
+
+// refreshInventoryChecksums updates checksums for the specified installed files,
+// or for all previously recorded files when none are specified
+func refreshInventoryChecksums(id string, ii *InstallInfo, rdx redux.Readable, relFiles ...string) error {
+
+	absInstalledDir, err := originOsInstalledPath(id, ii, rdx)
+	if err != nil {
+		return err
+	}
+
+	if len(relFiles) == 0 {
+		var checksums map[string]inventoryChecksum
+		if checksums, err = readInventoryChecksums(id, ii, rdx); err != nil {
+			return err
+		}
+		relFiles = slices.Sorted(maps.Keys(checksums))
+	}
+
+	return addInventoryChecksums(id, ii, rdx, absInstalledDir, relFiles...)
+}
+
+func cutInventoryChecksums(id string, ii *InstallInfo, rdx redux.Readable, relFiles ...string) error {
+
+	checksums, err := readInventoryChecksums(id, ii, rdx)
+	if err != nil {
+		return err
+	}
+
+	for _, relFile := range relFiles {
+		delete(checksums, relFile)
+	}
+
+	return writeInventoryChecksums(id, ii, rdx, checksums)
+}
+
+func removeInventoryChecksumsFile(id string, ii *InstallInfo, rdx redux.Readable) error {
+
+	absChecksumsFilename, err := data.AbsInventoryChecksumsFilename(id, ii.LangCode, ii.OperatingSystem, rdx)
+	if err != nil {
+		return err
+	}
+
+	return removeFileIfExists(absChecksumsFilename)
+}
+
+func vangoghUnpackedPayloadDir(operatingSystem vangogh_integration.OperatingSystem, unpackDir, localFilename string) string {
+	switch operatingSystem {
+	case vangogh_integration.MacOS:
+		return filepath.Join(unpackDir, localFilename, relPayloadPath)
+	case vangogh_integration.Linux:
+		return filepath.Join(unpackDir, localFilename, relExtractedDataPath)
+	default:
+		return filepath.Join(unpackDir, localFilename)
+	}
+}
+
+func vangoghRecordPayloadChecksums(id string, ii *InstallInfo, localFilenames []string, unpackDir string, rdx redux.Readable) error {
+
+	for _, localFilename := range localFilenames {
+
+		if !isExecutable(localFilename, ii.OperatingSystem) {
+			continue
+		}
+
+		absPayloadDir := vangoghUnpackedPayloadDir(ii.OperatingSystem, unpackDir, localFilename)
+		if _, err := os.Stat(absPayloadDir); os.IsNotExist(err) {
+			continue
+		}
+
+		relFiles, err := relWalkDir(absPayloadDir)
+		if err != nil {
+			return err
+		}
+
+		if err = addInventoryChecksums(id, ii, rdx, absPayloadDir, relFiles...); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
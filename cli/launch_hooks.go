@@ -0,0 +1,162 @@
+package cli
+
+import (
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+
+	"github.com/arelate/southern_light/vangogh_integration"
+	"github.com/arelate/theo/data"
+	"github.com/boggydigital/nod"
+	"github.com/boggydigital/redux"
+)
+
+type launchHook string
+
+const (
+	launchHookPreLaunch launchHook = "pre-launch"
+	launchHookPostExit  launchHook = "post-exit"
+)
+
+const (
+	launchHookIdEnv         = "THEO_ID"
+	launchHookTitleEnv      = "THEO_TITLE"
+	launchHookInstallDirEnv = "THEO_INSTALL_DIR"
+	launchHookPrefixEnv     = "THEO_PREFIX"
+	launchHookExitStatusEnv = "THEO_EXIT_STATUS"
+)
+
+// setLaunchHooks stores pre-launch and post-exit hooks for a product (AppOsLangCode)
+// or all products (GlobalLaunchOptionsKey)
+func setLaunchHooks(key string, et *execTask, reset bool, rdx redux.Writeable) error {
+
+	if err := rdx.MustHave(data.LaunchOptionsPreHookProperty, data.LaunchOptionsPostHookProperty); err != nil {
+		return err
+	}
+
+	if reset {
+		if err := rdx.CutKeys(data.LaunchOptionsPreHookProperty, key); err != nil {
+			return err
+		}
+		if err := rdx.CutKeys(data.LaunchOptionsPostHookProperty, key); err != nil {
+			return err
+		}
+	}
+
+	hookProperties := map[string][]string{
+		data.LaunchOptionsPreHookProperty:  et.preHooks,
+		data.LaunchOptionsPostHookProperty: et.postHooks,
+	}
+
+	for property, hooks := range hookProperties {
+
+		if len(hooks) == 0 {
+			continue
+		}
+
+		absHooks := make([]string, 0, len(hooks))
+
+		// hooks are run later from an unrelated working dir, so relative paths are resolved now
+		for _, hook := range hooks {
+			absHook, err := filepath.Abs(hook)
+			if err != nil {
+				return err
+			}
+			if err = checkLaunchHook(absHook); err != nil {
+				return err
+			}
+			absHooks = append(absHooks, absHook)
+		}
+
+		if err := rdx.ReplaceValues(property, key, absHooks...); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func checkLaunchHook(absHook string) error {
+
+	stat, err := os.Stat(absHook)
+	if err != nil {
+		return err
+	}
+
+	if !stat.Mode().IsRegular() {
+		return errors.New("launch hook is not a file: " + absHook)
+	}
+
+	// Windows doesn't use permission bits to mark files as executable
+	if vangogh_integration.CurrentOs() != vangogh_integration.Windows && stat.Mode().Perm()&0111 == 0 {
+		return errors.New("launch hook is not executable: " + absHook)
+	}
+
+	return nil
+}
+
+// launchHooksEnv returns environment variables that describe the product to hook scripts
+func launchHooksEnv(id string, ii *InstallInfo, et *execTask, rdx redux.Readable) ([]string, error) {
+
+	title, err := data.GetTitleProperty(id, rdx)
+	if err != nil {
+		return nil, err
+	}
+
+	absInstalledDir, err := originOsInstalledPath(id, ii, rdx)
+	if err != nil {
+		return nil, err
+	}
+
+	return []string{
+		launchHookIdEnv + "=" + id,
+		launchHookTitleEnv + "=" + title,
+		launchHookInstallDirEnv + "=" + absInstalledDir,
+		launchHookPrefixEnv + "=" + et.prefix,
+	}, nil
+}
+
+// runLaunchHooks runs hooks in order and stops at the first failing hook,
+// which allows pre-launch hooks to abort the launch
+func runLaunchHooks(hook launchHook, hooks []string, env []string, verbose bool) error {
+
+	if len(hooks) == 0 {
+		return nil
+	}
+
+	rlha := nod.Begin(" running %s hooks...", hook)
+	defer rlha.Done()
+
+	for _, absHookPath := range hooks {
+
+		cmd := exec.Command(absHookPath)
+		cmd.Env = append(os.Environ(), env...)
+
+		if verbose {
+			cmd.Stdout = os.Stdout
+			cmd.Stderr = os.Stderr
+		}
+
+		if err := cmd.Run(); err != nil {
+			return errors.New(string(hook) + " hook " + absHookPath + " failed: " + err.Error())
+		}
+	}
+
+	return nil
+}
+
+// exitStatus returns exit code of a finished process, or -1 when it couldn't be started
+func exitStatus(err error) int {
+
+	if err == nil {
+		return 0
+	}
+
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) {
+		return exitErr.ExitCode()
+	}
+
+	return -1
+}
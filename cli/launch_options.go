@@ -1,6 +1,7 @@
 package cli
 
 import (
+	"errors"
 	"maps"
 	"net/url"
 	"os"
@@ -50,8 +51,24 @@ func LaunchOptionsHandler(u *url.URL) error {
 		}
 	}
 
+	if q.Has(data.UrlPreHookParameter) {
+		et.preHooks = strings.Split(q.Get(data.UrlPreHookParameter), ",")
+	}
+
+	if q.Has(data.UrlPostHookParameter) {
+		et.postHooks = strings.Split(q.Get(data.UrlPostHookParameter), ",")
+	}
+
+	if q.Has(data.UrlWrapperParameter) {
+		et.wrappers = strings.Split(q.Get(data.UrlWrapperParameter), ",")
+	}
+
 	reset := q.Has(vangogh_integration.UrlResetParameter)
 
+	if q.Has(data.UrlGlobalParameter) {
+		return GlobalLaunchOptions(et, reset)
+	}
+
 	return LaunchOptions(id, ii, et, reset)
 }
 
@@ -116,7 +133,34 @@ func LaunchOptions(id string, request *InstallInfo, et *execTask, reset bool) er
 		}
 	}
 
-	return nil
+	if err = setLaunchHooks(appOsLangCode, et, reset, rdx); err != nil {
+		return err
+	}
+
+	return setLaunchWrappers(appOsLangCode, et, reset, rdx)
+}
+
+// GlobalLaunchOptions sets launch options that apply to all products. Only hooks and wrappers are supported,
+// since executables, arguments and environment are specific to each product
+func GlobalLaunchOptions(et *execTask, reset bool) error {
+
+	gloa := nod.Begin("setting global launch options...")
+	defer gloa.Done()
+
+	if et.exe != "" || len(et.args) > 0 || len(et.env) > 0 {
+		return errors.New("global launch options only support hooks and wrappers")
+	}
+
+	rdx, err := redux.NewWriter(vangogh_integration.AbsReduxDir(), data.AllProperties()...)
+	if err != nil {
+		return err
+	}
+
+	if err = setLaunchHooks(data.GlobalLaunchOptionsKey, et, reset, rdx); err != nil {
+		return err
+	}
+
+	return setLaunchWrappers(data.GlobalLaunchOptionsKey, et, reset, rdx)
 }
 
 func mergeEnv(env1 []string, env2 []string) []string {
@@ -0,0 +1,60 @@
+package cli
+
+import (
+	"errors"
+	"os/exec"
+	"strings"
+
+	"github.com/arelate/theo/data"
+	"github.com/boggydigital/redux"
+)
+
+// setLaunchWrappers stores wrapper commands (e.g. gamemoderun, mangohud or "gamescope -w 1280 -h 800 --")
+// for a product (AppOsLangCode) or all products (GlobalLaunchOptionsKey)
+func setLaunchWrappers(key string, et *execTask, reset bool, rdx redux.Writeable) error {
+
+	if err := rdx.MustHave(data.LaunchOptionsWrapperProperty); err != nil {
+		return err
+	}
+
+	if reset {
+		if err := rdx.CutKeys(data.LaunchOptionsWrapperProperty, key); err != nil {
+			return err
+		}
+	}
+
+	if len(et.wrappers) == 0 {
+		return nil
+	}
+
+	for _, wrapper := range et.wrappers {
+		fields := strings.Fields(wrapper)
+		if len(fields) == 0 {
+			return errors.New("wrapper cannot be empty")
+		}
+		if _, err := exec.LookPath(fields[0]); err != nil {
+			return err
+		}
+	}
+
+	return rdx.ReplaceValues(data.LaunchOptionsWrapperProperty, key, et.wrappers...)
+}
+
+// wrapCommand prepends wrappers in order to a command, e.g. wrappers "gamemoderun", "mangohud"
+// and command "game -arg" become "gamemoderun mangohud game -arg"
+func wrapCommand(wrappers []string, name string, args ...string) (string, []string) {
+
+	wrappedArgs := make([]string, 0)
+	for _, wrapper := range wrappers {
+		wrappedArgs = append(wrappedArgs, strings.Fields(wrapper)...)
+	}
+
+	if len(wrappedArgs) == 0 {
+		return name, args
+	}
+
+	wrappedArgs = append(wrappedArgs, name)
+	wrappedArgs = append(wrappedArgs, args...)
+
+	return wrappedArgs[0], wrappedArgs[1:]
+}
@@ -66,7 +66,9 @@ func linuxProtonExecTask(id string, et *execTask) error {
 		return err
 	}
 
-	cmd := exec.Command(absUmuRunPath, "--config", absUmuConfigPath)
+	name, args := wrapCommand(et.wrappers, absUmuRunPath, "--config", absUmuConfigPath)
+
+	cmd := exec.Command(name, args...)
 
 	if et.workDir != "" {
 		cmd.Dir = et.workDir
@@ -85,7 +87,7 @@ func linuxProtonExecTask(id string, et *execTask) error {
 		cmd.Stderr = os.Stderr
 	}
 
-	return cmd.Run()
+	return runProcessGroup(cmd, et)
 }
 
 func linuxInitPrefix(absPrefixDir string, _ bool) error {
@@ -157,11 +157,14 @@ func nixRunExecTask(et *execTask) error {
 
 	if vangogh_integration.CurrentOs() == vangogh_integration.MacOS &&
 		strings.HasSuffix(et.exe, appBundleExt) {
-		et.args = append([]string{et.exe, "--args"}, et.args...)
+		// wait for the app to quit, otherwise open returns as soon as the app is launched
+		et.args = append([]string{"-W", et.exe, "--args"}, et.args...)
 		et.exe = "open"
 	}
 
-	cmd := exec.Command(et.exe, et.args...)
+	name, args := wrapCommand(et.wrappers, et.exe, et.args...)
+
+	cmd := exec.Command(name, args...)
 	cmd.Dir = et.workDir
 
 	if et.verbose {
@@ -173,7 +176,7 @@ func nixRunExecTask(et *execTask) error {
 		cmd.Env = append(cmd.Env, e)
 	}
 
-	return cmd.Run()
+	return runProcessGroup(cmd, et)
 }
 
 func linuxFindStartSh(id string, ii *InstallInfo, rdx redux.Readable) (string, error) {
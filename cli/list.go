@@ -37,6 +37,7 @@ const (
 	ListTargetLaunchOptions
 	ListTargetSteamShortcuts
 	ListTargetTasks
+	ListTargetExtras
 )
 
 func ListHandler(u *url.URL) error {
@@ -56,6 +57,8 @@ func ListHandler(u *url.URL) error {
 		lt = ListTargetSteamShortcuts
 	} else if q.Has(vangogh_integration.UrlTasksParameter) {
 		lt = ListTargetTasks
+	} else if q.Has(data.UrlExtrasParameter) {
+		lt = ListTargetExtras
 	}
 
 	operatingSystem := vangogh_integration.AnyOperatingSystem
@@ -105,6 +108,12 @@ func List(lt listTarget,
 		}
 
 		return listTasks(id, installInfo)
+	case ListTargetExtras:
+		if id == "" {
+			return errors.New("listing extras requires product id")
+		}
+
+		return listExtras(id, installInfo)
 	case ListTargetUnknown:
 		return errors.New("you need to specify at least one category to list")
 	default:
@@ -187,7 +196,8 @@ func listInstalled(ii *InstallInfo) error {
 		data.InstallInfoProperty,
 		data.InstallDateProperty,
 		data.LastRunDateProperty,
-		data.TotalPlaytimeMinutesProperty)
+		data.TotalPlaytimeMinutesProperty,
+		data.UpdateAvailableProperty)
 	if err != nil {
 		return err
 	}
@@ -268,6 +278,15 @@ func listInstalled(ii *InstallInfo) error {
 				summary[titleLine] = append(summary[titleLine], "- dlc: "+strings.Join(installedInfo.DownloadableContent, ", "))
 			}
 
+			if updates, sure := rdx.GetAllValues(data.UpdateAvailableProperty, id); sure {
+				osLangCodePfx := data.OsLangCode(installedInfo.OperatingSystem, installedInfo.LangCode) + ": "
+				for _, update := range updates {
+					if strings.HasPrefix(update, osLangCodePfx) {
+						summary[titleLine] = append(summary[titleLine], "- update available: "+strings.TrimPrefix(update, osLangCodePfx))
+					}
+				}
+			}
+
 			if installedDate != "" {
 				installStr := "- installed: " + installedDate
 				if installDir != "" {
@@ -350,6 +369,9 @@ func listLaunchOptions(id string, request *InstallInfo) error {
 		data.LaunchOptionsExeProperty,
 		data.LaunchOptionsArgProperty,
 		data.LaunchOptionsEnvProperty,
+		data.LaunchOptionsPreHookProperty,
+		data.LaunchOptionsPostHookProperty,
+		data.LaunchOptionsWrapperProperty,
 	}
 
 	for _, lop := range launchOptionsProperties {
@@ -358,6 +380,12 @@ func listLaunchOptions(id string, request *InstallInfo) error {
 		}
 	}
 
+	for _, lop := range []string{data.LaunchOptionsPreHookProperty, data.LaunchOptionsPostHookProperty, data.LaunchOptionsWrapperProperty} {
+		if values, ok := rdx.GetAllValues(lop, data.GlobalLaunchOptionsKey); ok && len(values) > 0 {
+			summary[data.GlobalLaunchOptionsKey+" "+lop] = values
+		}
+	}
+
 	if len(summary) > 0 {
 		lloa.EndWithSummary("found launch options:", summary)
 	} else {
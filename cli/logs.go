@@ -0,0 +1,339 @@
+package cli
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/arelate/southern_light/vangogh_integration"
+	"github.com/arelate/theo/data"
+	"github.com/boggydigital/camino"
+	"github.com/boggydigital/nod"
+	"github.com/boggydigital/redux"
+)
+
+type runLogKind string
+
+const (
+	runLogKindRun     runLogKind = "run"
+	runLogKindInstall runLogKind = "install"
+	runLogKindProton  runLogKind = "proton"
+)
+
+const (
+	runLogExt = ".log"
+	// runLogsLimit is the number of logs kept for each product, older logs are removed
+	runLogsLimit = 10
+	// runLogsSeqLimit is the number of logs that can be created for a product within the same second
+	runLogsSeqLimit = 100
+	// last line of each log, followed by the exit status of the process
+	runLogExitStatusPfx = "theo: exit status "
+)
+
+const (
+	protonLogEnvName    = "PROTON_LOG"
+	protonLogDirEnvName = "PROTON_LOG_DIR"
+	// Proton names logs by the Steam app id, e.g. steam-12345.log
+	protonLogGlob = "steam-*.log"
+)
+
+func LogsHandler(u *url.URL) error {
+
+	q := u.Query()
+
+	id := q.Get(vangogh_integration.UrlIdParameter)
+
+	last := 1
+	if q.Has(data.UrlLastParameter) {
+		var err error
+		if last, err = strconv.Atoi(q.Get(data.UrlLastParameter)); err != nil {
+			return err
+		} else if last < 1 {
+			return errors.New("last logs number must be at least 1")
+		}
+	}
+
+	return Logs(id, last)
+}
+
+// Logs lists product run logs with exit statuses and prints the last logs
+func Logs(id string, last int) error {
+
+	la := nod.Begin("showing logs for %s...", id)
+	defer la.Done()
+
+	rdx, err := redux.NewReader(vangogh_integration.AbsReduxDir(), data.AllProperties()...)
+	if err != nil {
+		return err
+	}
+
+	absLogsDir, err := data.AbsProductLogsDir(id, rdx)
+	if err != nil {
+		return err
+	}
+
+	runLogs, err := productRunLogs(absLogsDir)
+	if err != nil {
+		return err
+	}
+
+	if len(runLogs) == 0 {
+		la.EndWithResult("found nothing")
+		return nil
+	}
+
+	summary := make(map[string][]string)
+
+	for _, absRunLogPath := range runLogs {
+		summary[absLogsDir] = append(summary[absLogsDir], runLogSummary(absRunLogPath))
+	}
+
+	la.EndWithSummary(fmt.Sprintf("found %d log(s):", len(runLogs)), summary)
+
+	for _, absRunLogPath := range runLogs[max(len(runLogs)-last, 0):] {
+		if err = printRunLog(absRunLogPath); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// productRunLogs returns product run, install and Proton logs, oldest first. Log filenames start
+// with the date, so they're sorted chronologically
+func productRunLogs(absLogsDir string) ([]string, error) {
+
+	if _, err := os.Stat(absLogsDir); os.IsNotExist(err) {
+		return nil, nil
+	}
+
+	entries, err := os.ReadDir(absLogsDir)
+	if err != nil {
+		return nil, err
+	}
+
+	runLogs := make([]string, 0, len(entries))
+
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() ||
+			!(strings.HasSuffix(name, string(runLogKindRun)+runLogExt) ||
+				strings.HasSuffix(name, string(runLogKindInstall)+runLogExt) ||
+				strings.HasSuffix(name, string(runLogKindProton)+runLogExt)) {
+			continue
+		}
+		runLogs = append(runLogs, filepath.Join(absLogsDir, name))
+	}
+
+	slices.Sort(runLogs)
+
+	return runLogs, nil
+}
+
+func runLogSummary(absRunLogPath string) string {
+
+	name := strings.TrimSuffix(filepath.Base(absRunLogPath), runLogExt)
+
+	parts := make([]string, 0, 4)
+
+	dateStr, kind := name, ""
+	if len(name) > len(camino.Layout) {
+		dateStr, kind = name[:len(camino.Layout)], strings.TrimPrefix(name[len(camino.Layout):], "-")
+		// logs created within the same second are numbered
+		if seq, seqKind, ok := strings.Cut(kind, "-"); ok {
+			if _, err := strconv.Atoi(seq); err == nil {
+				kind = seqKind
+			}
+		}
+	}
+
+	if dt, err := time.ParseInLocation(camino.Layout, dateStr, time.Local); err == nil {
+		dateStr = dt.Format(time.DateTime)
+	}
+
+	parts = append(parts, dateStr, kind)
+
+	// Proton logs are written by Proton and don't record the exit status
+	if kind != string(runLogKindProton) {
+		if exitStatus, ok := runLogExitStatus(absRunLogPath); ok {
+			parts = append(parts, "exit status: "+exitStatus)
+		} else {
+			parts = append(parts, "exit status: unknown")
+		}
+	}
+
+	if fi, err := os.Stat(absRunLogPath); err == nil {
+		parts = append(parts, vangogh_integration.FormatBytes(fi.Size()))
+	}
+
+	return strings.Join(parts, "; ")
+}
+
+// runLogExitStatus returns the exit status recorded at the end of the log,
+// logs of runs that are still in progress or were interrupted don't have it
+func runLogExitStatus(absRunLogPath string) (string, bool) {
+
+	runLogFile, err := os.Open(absRunLogPath)
+	if err != nil {
+		return "", false
+	}
+	defer runLogFile.Close()
+
+	var exitStatus string
+	var ok bool
+
+	scanner := bufio.NewScanner(runLogFile)
+	for scanner.Scan() {
+		if es, found := strings.CutPrefix(scanner.Text(), runLogExitStatusPfx); found {
+			exitStatus, ok = es, true
+		}
+	}
+
+	return exitStatus, ok
+}
+
+func printRunLog(absRunLogPath string) error {
+
+	runLogFile, err := os.Open(absRunLogPath)
+	if err != nil {
+		return err
+	}
+	defer runLogFile.Close()
+
+	fmt.Println("==> " + absRunLogPath)
+
+	_, err = io.Copy(os.Stdout, runLogFile)
+	return err
+}
+
+// newRunLog creates a new product log and returns its path, removing the oldest logs
+// over the limit. Logs are named by the date, sequence number and kind,
+// e.g. 2026-01-02-15-04-05-00-run.log
+func newRunLog(id string, kind runLogKind, rdx redux.Readable) (string, error) {
+
+	absLogsDir, err := data.AbsProductLogsDir(id, rdx)
+	if err != nil {
+		return "", err
+	}
+
+	if _, err = os.Stat(absLogsDir); os.IsNotExist(err) {
+		if err = os.MkdirAll(absLogsDir, camino.DefaultFileMode); err != nil {
+			return "", err
+		}
+	}
+
+	runLogs, err := productRunLogs(absLogsDir)
+	if err != nil {
+		return "", err
+	}
+
+	// make room for the new log
+	for len(runLogs) >= runLogsLimit {
+		if err = os.Remove(runLogs[0]); err != nil {
+			return "", err
+		}
+		runLogs = runLogs[1:]
+	}
+
+	dateStr := time.Now().Format(camino.Layout)
+
+	// sequence number keeps logs created within the same second apart (e.g. multiple installers),
+	// exclusive creation makes sure an existing log is never reused
+	for seq := 0; seq < runLogsSeqLimit; seq++ {
+
+		absRunLogPath := filepath.Join(absLogsDir, fmt.Sprintf("%s-%02d-%s%s", dateStr, seq, kind, runLogExt))
+
+		runLogFile, err := os.OpenFile(absRunLogPath, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0644)
+		if os.IsExist(err) {
+			continue
+		} else if err != nil {
+			return "", err
+		}
+
+		return absRunLogPath, runLogFile.Close()
+	}
+
+	return "", errors.New("too many logs created at " + dateStr)
+}
+
+// protonLogEnv enables Proton logging into the product logs directory
+func protonLogEnv(id string, rdx redux.Readable) ([]string, error) {
+
+	absLogsDir, err := data.AbsProductLogsDir(id, rdx)
+	if err != nil {
+		return nil, err
+	}
+
+	return []string{
+		protonLogEnvName + "=1",
+		protonLogDirEnvName + "=" + absLogsDir,
+	}, nil
+}
+
+// renameProtonLogs names Proton logs written during the run after the run log,
+// e.g. 2026-01-02-15-04-05-00-proton.log, so they're numbered and rotated with other logs
+func renameProtonLogs(absRunLogPath string) error {
+
+	absProtonLogs, err := filepath.Glob(filepath.Join(filepath.Dir(absRunLogPath), protonLogGlob))
+	if err != nil {
+		return err
+	}
+
+	absProtonLogPath := strings.TrimSuffix(absRunLogPath, string(runLogKindRun)+runLogExt) + string(runLogKindProton) + runLogExt
+
+	for _, absLogPath := range absProtonLogs {
+		if err = os.Rename(absLogPath, absProtonLogPath); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// openRunLog captures command output into the run log, in addition to any output
+// already set for the command (e.g. terminal in verbose mode)
+func openRunLog(cmd *exec.Cmd, et *execTask) (*os.File, error) {
+
+	runLogFile, err := os.OpenFile(et.absLogPath, os.O_WRONLY|os.O_TRUNC, 0644)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err = fmt.Fprintf(runLogFile, "theo: %s %s\n", cmd.Path, strings.Join(cmd.Args[1:], " ")); err != nil {
+		return nil, errors.Join(err, runLogFile.Close())
+	}
+
+	switch cmd.Stdout {
+	case nil:
+		cmd.Stdout = runLogFile
+	default:
+		cmd.Stdout = io.MultiWriter(cmd.Stdout, runLogFile)
+	}
+
+	switch cmd.Stderr {
+	case nil:
+		cmd.Stderr = runLogFile
+	default:
+		cmd.Stderr = io.MultiWriter(cmd.Stderr, runLogFile)
+	}
+
+	return runLogFile, nil
+}
+
+func closeRunLog(runLogFile *os.File, runErr error) error {
+
+	if _, err := fmt.Fprintf(runLogFile, "\n%s%d\n", runLogExitStatusPfx, exitStatus(runErr)); err != nil {
+		return err
+	}
+
+	return runLogFile.Close()
+}
@@ -74,7 +74,7 @@ func macOsWineExecTask(id string, et *execTask) error {
 		cmd.Stderr = os.Stderr
 	}
 
-	return cmd.Run()
+	return runProcessGroup(cmd, et)
 }
 
 func macOsGetAbsCxBinDir(rdx redux.Readable) (string, error) {
@@ -0,0 +1,139 @@
+package cli
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/boggydigital/nod"
+)
+
+const (
+	playSessionPollInterval = 2 * time.Second
+	// launchers often start the game and exit, the grace period allows the game process to start
+	playSessionGracePeriod = 15 * time.Second
+)
+
+const (
+	procDir                    = "/proc"
+	wineserverName             = "wineserver"
+	winePrefixEnvName          = "WINEPREFIX"
+	steamCompatDataPathEnvName = "STEAM_COMPAT_DATA_PATH"
+)
+
+// runProcessGroup runs a command in a new process group, so that all processes started
+// by the command (launchers, games, WINE processes) can be tracked as one play session.
+// Command output is captured into the run log, when one is set for the task
+func runProcessGroup(cmd *exec.Cmd, et *execTask) error {
+
+	setProcessGroup(cmd)
+
+	var runLogFile *os.File
+	if et.absLogPath != "" {
+		var err error
+		if runLogFile, err = openRunLog(cmd, et); err != nil {
+			return err
+		}
+	}
+
+	// failing to record the session shouldn't interrupt the product that is already running
+	var sessionErr error
+
+	runErr := cmd.Start()
+	if runErr == nil {
+		et.pgid = cmd.Process.Pid
+		if et.session != nil {
+			et.session.Pid, et.session.Pgid = cmd.Process.Pid, et.pgid
+			sessionErr = writeRunningSession(et.session)
+		}
+		runErr = cmd.Wait()
+	}
+
+	if runLogFile != nil {
+		if err := closeRunLog(runLogFile, runErr); err != nil {
+			return err
+		}
+	}
+
+	return errors.Join(runErr, sessionErr)
+}
+
+// waitPlaySession waits until no processes of the play session remain and returns the time
+// when the last of them was seen running. Play session processes are the process group
+// of the launched command and the wineserver of the prefix, if any
+func waitPlaySession(et *execTask) time.Time {
+
+	lastSeen := time.Now()
+
+	if et.pgid == 0 {
+		return lastSeen
+	}
+
+	wpsa := nod.Begin(" waiting for %s processes to exit...", et.title)
+	defer wpsa.Done()
+
+	for {
+		if processGroupRunning(et.pgid) || len(prefixWineserverPids(et.prefix)) > 0 {
+			lastSeen = time.Now()
+		} else if time.Since(lastSeen) > playSessionGracePeriod {
+			return lastSeen
+		}
+
+		time.Sleep(playSessionPollInterval)
+	}
+}
+
+// prefixWineserverPids returns wineserver processes that serve the prefix.
+// Process environment is only available on Linux, on other operating systems nothing is returned
+func prefixWineserverPids(absPrefixDir string) []int {
+
+	if absPrefixDir == "" {
+		return nil
+	}
+
+	entries, err := os.ReadDir(procDir)
+	if err != nil {
+		return nil
+	}
+
+	pids := make([]int, 0)
+
+	for _, entry := range entries {
+
+		pid, err := strconv.Atoi(entry.Name())
+		if err != nil {
+			continue
+		}
+
+		absProcDir := filepath.Join(procDir, entry.Name())
+
+		comm, err := os.ReadFile(filepath.Join(absProcDir, "comm"))
+		if err != nil || strings.TrimSpace(string(comm)) != wineserverName {
+			continue
+		}
+
+		environ, err := os.ReadFile(filepath.Join(absProcDir, "environ"))
+		if err != nil {
+			continue
+		}
+
+		for _, env := range bytes.Split(environ, []byte{0}) {
+			name, value, ok := strings.Cut(string(env), "=")
+			if !ok || (name != winePrefixEnvName && name != steamCompatDataPathEnvName) {
+				continue
+			}
+			// Proton uses pfx subdirectory of the prefix as WINEPREFIX
+			if strings.HasPrefix(value, absPrefixDir) {
+				pids = append(pids, pid)
+				break
+			}
+		}
+	}
+
+	return pids
+}
@@ -22,7 +22,13 @@ const (
 
 const lnkGlob = "*.lnk"
 
-const prefixRelDriveCDir = "drive_c"
+// WINE maps the root of the host file system to Z: drive
+const wineRootDrive = "Z:"
+
+const (
+	prefixRelDriveCDir = "drive_c"
+	prefixRelUsersDir  = "users"
+)
 
 func prefixInit(id string, origin data.Origin, rdx redux.Readable, verbose bool) error {
 
@@ -44,6 +50,10 @@ func prefixInit(id string, origin data.Origin, rdx redux.Readable, verbose bool)
 	}
 }
 
+func nixToWinePath(absPath string) string {
+	return wineRootDrive + strings.Replace(absPath, "/", "\\", -1)
+}
+
 func prefixTempUnpackDir(id string, origin data.Origin, rdx redux.Readable) (string, error) {
 	absPrefixDir, err := data.AbsPrefixDir(id, origin, rdx)
 	if err != nil {
@@ -96,6 +106,10 @@ func prefixRunInstallers(id string, ii *InstallInfo, localFilenames []string, rd
 			verbose: ii.verbose,
 		}
 
+		if et.absLogPath, err = newRunLog(id, runLogKindInstall, rdx); err != nil {
+			return err
+		}
+
 		switch vangogh_integration.CurrentOs() {
 		case vangogh_integration.MacOS:
 			if err = macOsWineExecTask(id, et); err != nil {
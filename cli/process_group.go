@@ -0,0 +1,43 @@
+//go:build !windows
+
+package cli
+
+import (
+	"errors"
+	"os/exec"
+	"syscall"
+)
+
+func setProcessGroup(cmd *exec.Cmd) {
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+}
+
+// processGroupRunning sends signal 0 to the process group, which only checks whether it exists
+func processGroupRunning(pgid int) bool {
+	return processRunning(-pgid)
+}
+
+// processRunning sends signal 0 to the process, which only checks whether it exists
+func processRunning(pid int) bool {
+	err := syscall.Kill(pid, 0)
+	return err == nil || errors.Is(err, syscall.EPERM)
+}
+
+// signalProcessGroup asks all processes in the group to terminate, or kills them when forced
+func signalProcessGroup(pgid int, force bool) error {
+	return signalProcess(-pgid, force)
+}
+
+func signalProcess(pid int, force bool) error {
+
+	sig := syscall.SIGTERM
+	if force {
+		sig = syscall.SIGKILL
+	}
+
+	if err := syscall.Kill(pid, sig); err != nil && !errors.Is(err, syscall.ESRCH) {
+		return err
+	}
+
+	return nil
+}
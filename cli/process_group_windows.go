@@ -0,0 +1,24 @@
+package cli
+
+import (
+	"errors"
+	"os/exec"
+)
+
+func setProcessGroup(_ *exec.Cmd) {}
+
+func processGroupRunning(_ int) bool {
+	return false
+}
+
+func processRunning(_ int) bool {
+	return false
+}
+
+func signalProcessGroup(_ int, _ bool) error {
+	return errors.New("stopping process groups is not supported on Windows")
+}
+
+func signalProcess(_ int, _ bool) error {
+	return errors.New("stopping processes is not supported on Windows")
+}
@@ -0,0 +1,511 @@
+package cli
+
+import (
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/arelate/southern_light/steam_vdf"
+	"github.com/arelate/southern_light/vangogh_integration"
+	"github.com/arelate/theo/data"
+	"github.com/boggydigital/camino"
+	"github.com/boggydigital/nod"
+	"github.com/boggydigital/redux"
+)
+
+type reconcileIssue string
+
+const (
+	reconcileMissingDir       reconcileIssue = "missing installation dir"
+	reconcileMissingInventory reconcileIssue = "missing inventory"
+	reconcileOrphanedDir      reconcileIssue = "orphaned installation dir"
+	reconcileOrphanedPrefix   reconcileIssue = "orphaned prefix"
+	reconcileDanglingShortcut reconcileIssue = "dangling Steam shortcut"
+)
+
+type reconcileFixes struct {
+	unpin  bool
+	adopt  bool
+	remove bool
+	force  bool
+}
+
+// reconcileMismatch is a single difference between pinned install info and the filesystem.
+// Depending on the issue, only some of the fields are set
+type reconcileMismatch struct {
+	issue       reconcileIssue
+	id          string
+	installInfo *InstallInfo
+	absPath     string
+	loginUser   string
+	shortcutId  uint32
+}
+
+func (rm *reconcileMismatch) String() string {
+	var parts []string
+	if rm.id != "" {
+		parts = append(parts, rm.id)
+	}
+	if rm.installInfo != nil {
+		parts = append(parts, fmt.Sprintf("%s %s", rm.installInfo.Origin, data.OsLangCode(rm.installInfo.OperatingSystem, rm.installInfo.LangCode)))
+	}
+	if rm.loginUser != "" {
+		parts = append(parts, "Steam user "+rm.loginUser)
+	}
+	if rm.absPath != "" {
+		parts = append(parts, rm.absPath)
+	}
+	return strings.Join(parts, "; ")
+}
+
+// adoptsInventory returns true when adopting would record all current files as inventory,
+// including any files added after installation (e.g. mods) that uninstall would then remove
+func (rm *reconcileMismatch) adoptsInventory() bool {
+	switch rm.issue {
+	case reconcileMissingInventory:
+		return true
+	case reconcileOrphanedDir:
+		return rm.id != "" && rm.installInfo != nil && rm.installInfo.Origin == data.VangoghOrigin
+	default:
+		return false
+	}
+}
+
+func ReconcileHandler(u *url.URL) error {
+
+	q := u.Query()
+
+	fixes := &reconcileFixes{
+		unpin:  q.Has(data.UrlUnpinParameter),
+		adopt:  q.Has(data.UrlAdoptParameter),
+		remove: q.Has(vangogh_integration.UrlRemoveParameter),
+		force:  q.Has(vangogh_integration.UrlForceParameter),
+	}
+
+	return Reconcile(fixes)
+}
+
+func Reconcile(fixes *reconcileFixes) error {
+
+	ra := nod.Begin("reconciling installations with the filesystem...")
+	defer ra.Done()
+
+	rdx, err := redux.NewWriter(vangogh_integration.AbsReduxDir(), data.AllProperties()...)
+	if err != nil {
+		return err
+	}
+
+	installedInfos, err := allInstalledInfos(rdx)
+	if err != nil {
+		return err
+	}
+
+	mismatches, err := reconcileInstalledInfos(installedInfos, rdx)
+	if err != nil {
+		return err
+	}
+
+	orphanedDirs, err := reconcileInstalledDirs(installedInfos, rdx)
+	if err != nil {
+		return err
+	}
+	mismatches = append(mismatches, orphanedDirs...)
+
+	prefixItems, err := gcPrefixesItems(installedInfos, rdx)
+	if err != nil {
+		return err
+	}
+	for _, item := range prefixItems {
+		mismatches = append(mismatches, &reconcileMismatch{issue: reconcileOrphanedPrefix, absPath: item.absPath})
+	}
+
+	danglingShortcuts, err := reconcileSteamShortcuts(installedInfos)
+	if err != nil {
+		return err
+	}
+	mismatches = append(mismatches, danglingShortcuts...)
+
+	if len(mismatches) == 0 {
+		ra.EndWithResult("everything is consistent")
+		return nil
+	}
+
+	summary := make(map[string][]string)
+
+	fixed := 0
+	for _, rm := range mismatches {
+
+		line := rm.String()
+
+		if fix, err := fixReconcileMismatch(rm, fixes, rdx); err != nil {
+			return err
+		} else if fix != "" {
+			line += " (" + fix + ")"
+			fixed++
+		} else if fixes.adopt && rm.adoptsInventory() {
+			line += " (not adopted, inventory would include all files, e.g. mods, use -force to adopt)"
+		}
+
+		heading := string(rm.issue) + ":"
+		summary[heading] = append(summary[heading], line)
+	}
+
+	heading := fmt.Sprintf("found %d mismatch(es), fixed %d:", len(mismatches), fixed)
+	if fixed < len(mismatches) {
+		heading = fmt.Sprintf("found %d mismatch(es), fixed %d, use -unpin, -adopt or -remove to fix the rest:", len(mismatches), fixed)
+	}
+
+	ra.EndWithSummary(heading, summary)
+
+	return nil
+}
+
+// reconcileInstalledInfos checks that every pinned install info has installation dir
+// and, for vangogh products, inventory
+func reconcileInstalledInfos(installedInfos map[string][]InstallInfo, rdx redux.Readable) ([]*reconcileMismatch, error) {
+
+	mismatches := make([]*reconcileMismatch, 0)
+
+	for id, iis := range installedInfos {
+		for _, ii := range iis {
+
+			absInstalledDir, err := originOsInstalledPath(id, &ii, rdx)
+			if err != nil {
+				return nil, err
+			}
+
+			if _, err = os.Stat(absInstalledDir); os.IsNotExist(err) {
+				mismatches = append(mismatches, &reconcileMismatch{
+					issue:       reconcileMissingDir,
+					id:          id,
+					installInfo: &ii,
+					absPath:     absInstalledDir,
+				})
+				continue
+			}
+
+			if ii.Origin != data.VangoghOrigin {
+				continue
+			}
+
+			var absInventoryFilename string
+			if absInventoryFilename, err = data.AbsInventoryFilename(id, ii.LangCode, ii.OperatingSystem, rdx); err != nil {
+				return nil, err
+			}
+
+			if _, err = os.Stat(absInventoryFilename); os.IsNotExist(err) {
+				mismatches = append(mismatches, &reconcileMismatch{
+					issue:       reconcileMissingInventory,
+					id:          id,
+					installInfo: &ii,
+					absPath:     absInventoryFilename,
+				})
+			}
+		}
+	}
+
+	return mismatches, nil
+}
+
+// reconcileInstalledDirs checks every installation dir under the origin install roots
+// and returns the ones that don't belong to any installed product. When an orphaned dir
+// matches a known product title, the mismatch includes product id, so it can be adopted
+func reconcileInstalledDirs(installedInfos map[string][]InstallInfo, rdx redux.Readable) ([]*reconcileMismatch, error) {
+
+	installedDirs := make([]string, 0)
+	for id, iis := range installedInfos {
+		for _, ii := range iis {
+			absInstalledDir, err := originOsInstalledPath(id, &ii, rdx)
+			if err != nil {
+				return nil, err
+			}
+			installedDirs = append(installedDirs, absInstalledDir)
+		}
+	}
+
+	originRoots := map[data.Origin]string{
+		data.VangoghOrigin:   camino.GetRel(vangogh_integration.GogApps, vangogh_integration.InstalledApps),
+		data.SteamOrigin:     camino.GetRel(vangogh_integration.SteamApps, vangogh_integration.InstalledApps),
+		data.EpicGamesOrigin: camino.GetRel(vangogh_integration.EgsApps, vangogh_integration.InstalledApps),
+	}
+
+	originTitleProperties := map[data.Origin]string{
+		data.VangoghOrigin:   vangogh_integration.GogTitleProperty,
+		data.SteamOrigin:     vangogh_integration.SteamTitleProperty,
+		data.EpicGamesOrigin: vangogh_integration.EgsTitleProperty,
+	}
+
+	mismatches := make([]*reconcileMismatch, 0)
+
+	for origin, absRootDir := range originRoots {
+
+		// vangogh installations are grouped by operating system and language code,
+		// Steam and EGS installations are grouped by operating system
+		osDirs, err := os.ReadDir(absRootDir)
+		if os.IsNotExist(err) {
+			continue
+		} else if err != nil {
+			return nil, err
+		}
+
+		for _, osDir := range osDirs {
+
+			if !osDir.IsDir() {
+				continue
+			}
+
+			ii := &InstallInfo{Origin: origin}
+
+			switch origin {
+			case data.VangoghOrigin:
+				osStr, langCode, _ := strings.Cut(osDir.Name(), "-")
+				ii.OperatingSystem = vangogh_integration.ParseOperatingSystem(osStr)
+				ii.LangCode = langCode
+			default:
+				ii.OperatingSystem = vangogh_integration.ParseOperatingSystem(osDir.Name())
+			}
+
+			absOsDir := filepath.Join(absRootDir, osDir.Name())
+
+			var appDirs []os.DirEntry
+			if appDirs, err = os.ReadDir(absOsDir); err != nil {
+				return nil, err
+			}
+
+			for _, appDir := range appDirs {
+
+				if !appDir.IsDir() {
+					continue
+				}
+
+				absAppDir := filepath.Join(absOsDir, appDir.Name())
+
+				if reconcileDirInUse(absAppDir, installedDirs) {
+					continue
+				}
+
+				mismatch := &reconcileMismatch{
+					issue:   reconcileOrphanedDir,
+					absPath: absAppDir,
+				}
+
+				if id := reconcileTitleId(originTitleProperties[origin], appDir.Name(), rdx); id != "" {
+					mismatch.id = id
+					mismatch.installInfo = ii
+				}
+
+				mismatches = append(mismatches, mismatch)
+			}
+		}
+	}
+
+	return mismatches, nil
+}
+
+func reconcileDirInUse(absDir string, installedDirs []string) bool {
+	for _, installedDir := range installedDirs {
+		// macOS vangogh installations include bundle name
+		if installedDir == absDir || strings.HasPrefix(installedDir, absDir+string(os.PathSeparator)) {
+			return true
+		}
+	}
+	return false
+}
+
+// reconcileTitleId returns product id which title matches installation dir name
+func reconcileTitleId(titleProperty, dirName string, rdx redux.Readable) string {
+
+	if err := rdx.MustHave(titleProperty); err != nil {
+		return ""
+	}
+
+	for id := range rdx.Keys(titleProperty) {
+		if title, ok := rdx.GetLastVal(titleProperty, id); ok && camino.Sanitize(title) == dirName {
+			return id
+		}
+	}
+
+	return ""
+}
+
+// reconcileSteamShortcuts returns theo Steam shortcuts for products that are not installed
+// or which start dir doesn't exist
+func reconcileSteamShortcuts(installedInfos map[string][]InstallInfo) ([]*reconcileMismatch, error) {
+
+	if ok, err := steamStateDirExist(); err != nil {
+		return nil, err
+	} else if !ok {
+		return nil, nil
+	}
+
+	loginUsers, err := getSteamLoginUsers()
+	if err != nil {
+		return nil, err
+	}
+
+	mismatches := make([]*reconcileMismatch, 0)
+
+	for _, loginUser := range loginUsers {
+
+		var kvUserShortcuts steam_vdf.ValveDataFile
+		if kvUserShortcuts, err = readUserShortcuts(loginUser); err != nil {
+			return nil, err
+		}
+
+		if kvUserShortcuts == nil {
+			continue
+		}
+
+		var kvShortcuts *steam_vdf.KeyValues
+		if kvShortcuts, err = kvUserShortcuts.At("shortcuts"); err != nil {
+			return nil, err
+		}
+
+		for _, shortcut := range kvShortcuts.Values {
+
+			var shortcutId uint32
+			var startDir, launchOptions string
+
+			for _, kv := range shortcut.Values {
+				switch kv.Key {
+				case "appid":
+					if appId, ok := kv.TypedValue.(uint32); ok {
+						shortcutId = appId
+					}
+				case "StartDir":
+					if sd, ok := kv.TypedValue.(string); ok {
+						startDir = strings.Trim(sd, "\"")
+					}
+				case "LaunchOptions":
+					if lo, ok := kv.TypedValue.(string); ok {
+						launchOptions = lo
+					}
+				}
+			}
+
+			id := shortcutProductId(launchOptions)
+			if id == "" {
+				// not a theo shortcut
+				continue
+			}
+
+			_, installed := installedInfos[id]
+			if _, err = os.Stat(startDir); installed && err == nil {
+				continue
+			}
+
+			mismatches = append(mismatches, &reconcileMismatch{
+				issue:      reconcileDanglingShortcut,
+				id:         id,
+				absPath:    startDir,
+				loginUser:  loginUser,
+				shortcutId: shortcutId,
+			})
+		}
+	}
+
+	return mismatches, nil
+}
+
+// shortcutProductId returns product id from theo shortcut launch options, e.g. "run -id 1234567890"
+func shortcutProductId(launchOptions string) string {
+
+	fields := strings.Fields(launchOptions)
+	if len(fields) < 3 || fields[0] != runTemplate {
+		return ""
+	}
+
+	idFlag, _, _ := strings.Cut(idTemplate, " ")
+	if fields[1] != idFlag {
+		return ""
+	}
+
+	return fields[2]
+}
+
+// fixReconcileMismatch applies requested fix to a mismatch and returns a description
+// of the applied fix, or an empty string if the mismatch was not fixed
+func fixReconcileMismatch(rm *reconcileMismatch, fixes *reconcileFixes, rdx redux.Writeable) (string, error) {
+
+	switch rm.issue {
+	case reconcileMissingDir:
+		if fixes.unpin {
+			return "unpinned", unpinInstallInfo(rm.id, rm.installInfo, rdx)
+		}
+	case reconcileMissingInventory:
+		if fixes.adopt && fixes.force {
+			return "adopted existing files", adoptInventory(rm.id, rm.installInfo, rdx)
+		}
+	case reconcileOrphanedDir:
+		if fixes.adopt && rm.id != "" {
+			if rm.adoptsInventory() && !fixes.force {
+				return "", nil
+			}
+			return "adopted as " + rm.id, adoptInstalledDir(rm.id, rm.installInfo, rdx)
+		}
+		if fixes.remove {
+			return "removed", os.RemoveAll(rm.absPath)
+		}
+	case reconcileOrphanedPrefix:
+		if fixes.remove {
+			return "removed", os.RemoveAll(rm.absPath)
+		}
+	case reconcileDanglingShortcut:
+		if fixes.remove {
+			return "removed", removeUserShortcut(rm.loginUser, rm.shortcutId)
+		}
+	}
+
+	return "", nil
+}
+
+// adoptInventory records all current installation files as product inventory
+func adoptInventory(id string, ii *InstallInfo, rdx redux.Readable) error {
+
+	absInstalledDir, err := originOsInstalledPath(id, ii, rdx)
+	if err != nil {
+		return err
+	}
+
+	relFiles, err := relWalkDir(absInstalledDir)
+	if err != nil {
+		return err
+	}
+
+	return appendInventory(id, ii.LangCode, ii.OperatingSystem, rdx, relFiles...)
+}
+
+func adoptInstalledDir(id string, ii *InstallInfo, rdx redux.Writeable) error {
+
+	if err := pinInstallInfo(id, ii, rdx); err != nil {
+		return err
+	}
+
+	if ii.Origin == data.VangoghOrigin {
+		return adoptInventory(id, ii, rdx)
+	}
+
+	return nil
+}
+
+func removeUserShortcut(loginUser string, shortcutId uint32) error {
+
+	if err := removeSteamGridImages(loginUser, shortcutId); err != nil {
+		return err
+	}
+
+	kvUserShortcuts, err := readUserShortcuts(loginUser)
+	if err != nil {
+		return err
+	}
+
+	if changed, err := removeNonSteamAppShortcut(shortcutId, kvUserShortcuts); err != nil {
+		return err
+	} else if changed {
+		return writeUserShortcuts(loginUser, kvUserShortcuts)
+	}
+
+	return nil
+}
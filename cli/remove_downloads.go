@@ -2,6 +2,7 @@ package cli
 
 import (
 	"net/url"
+	"strings"
 
 	"github.com/arelate/southern_light/vangogh_integration"
 	"github.com/arelate/theo/data"
@@ -29,9 +30,17 @@ func RemoveDownloadsHandler(u *url.URL) error {
 	ii := &InstallInfo{
 		OperatingSystem: operatingSystem,
 		LangCode:        langCode,
+		Origin:          data.VangoghOrigin,
 		force:           q.Has(vangogh_integration.UrlForceParameter),
 	}
 
+	if q.Has(vangogh_integration.UrlDownloadTypeParameter) {
+		var err error
+		if ii.downloadTypes, err = parseDownloadTypes(strings.Split(q.Get(vangogh_integration.UrlDownloadTypeParameter), ",")); err != nil {
+			return err
+		}
+	}
+
 	rdx, err := redux.NewWriter(vangogh_integration.AbsReduxDir(), data.AllProperties()...)
 	if err != nil {
 		return err
@@ -73,6 +82,11 @@ func originRemoveDownloads(id string, ii *InstallInfo, originData *data.OriginDa
 		if err := vangoghRemoveProductDownloadLinks(id, originData, ii, downloadsDir); err != nil {
 			return err
 		}
+		if ii.includesDownloadType(vangogh_integration.Extra) {
+			if err := vangoghRemoveExtras(id, originData, rdx); err != nil {
+				return err
+			}
+		}
 	case data.SteamOrigin:
 	// do nothing
 	case data.EpicGamesOrigin:
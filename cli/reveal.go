@@ -37,14 +37,15 @@ func RevealHandler(u *url.URL) error {
 	installed := q.Has(vangogh_integration.UrlInstalledParameter)
 	downloads := q.Has(vangogh_integration.UrlDownloadsParameter)
 	backups := q.Has(vangogh_integration.UrlBackupsParameter)
+	extras := q.Has(data.UrlExtrasParameter)
 
-	return Reveal(id, ii, installed, downloads, backups)
+	return Reveal(id, ii, installed, downloads, backups, extras)
 }
 
-func Reveal(id string, ii *InstallInfo, installed, downloads, backups bool) error {
+func Reveal(id string, ii *InstallInfo, installed, downloads, backups, extras bool) error {
 
-	if !(installed || downloads || backups) {
-		return errors.New("reveal requires target: installed, downloads, backups")
+	if !(installed || downloads || backups || extras) {
+		return errors.New("reveal requires target: installed, downloads, backups, extras")
 	}
 
 	if installed {
@@ -70,6 +71,12 @@ func Reveal(id string, ii *InstallInfo, installed, downloads, backups bool) erro
 		}
 	}
 
+	if extras {
+		if err := revealExtras(id); err != nil {
+			return err
+		}
+	}
+
 	return nil
 }
 
@@ -2,7 +2,9 @@ package cli
 
 import (
 	"errors"
+	"fmt"
 	"net/url"
+	"os"
 	"path/filepath"
 	"strconv"
 	"strings"
@@ -23,6 +25,15 @@ type execTask struct {
 	args               []string
 	clearArgs          bool
 	env                []string
+	preHooks           []string
+	postHooks          []string
+	wrappers           []string
+	pgid               int
+	absLogPath         string
+	session            *runningSession
+	attach             bool
+	newInstance        bool
+	protonLog          bool
 	protonOptions      []string
 	protonRuntime      string
 	steamProtonRuntime string
@@ -59,6 +70,9 @@ func RunHandler(u *url.URL) error {
 		verbose:         q.Has(vangogh_integration.UrlVerboseParameter),
 		task:            q.Get(vangogh_integration.UrlTaskParameter),
 		defaultLauncher: q.Has(vangogh_integration.UrlDefaultLauncherParameter),
+		protonLog:       q.Has(data.UrlProtonLogParameter),
+		attach:          q.Has(data.UrlAttachParameter),
+		newInstance:     q.Has(data.UrlNewInstanceParameter),
 	}
 
 	if q.Has(vangogh_integration.UrlEnvParameter) {
@@ -127,6 +141,41 @@ func Run(id string, request *InstallInfo, et *execTask) error {
 		false,
 		false)
 
+	// running the same product or another product with the same prefix twice
+	// can corrupt saves and WINE registry
+	if !et.newInstance {
+
+		var absPrefixDir string
+		if absPrefixDir, err = data.AbsPrefixDir(id, ii.Origin, rdx); err != nil {
+			return err
+		}
+
+		// locks are taken before checking running sessions and held until Run returns,
+		// so that another theo process can't start the product in between
+		var runLocks []*os.File
+		if runLocks, err = lockRun(id, absPrefixDir); err != nil {
+			return err
+		}
+		defer unlockRun(runLocks)
+
+		// processes started by a terminated theo process might still be running
+		var rs *runningSession
+		if rs, err = findRunningSession(id, absPrefixDir); err != nil {
+			return err
+		}
+
+		if rs != nil {
+			if et.attach {
+				return attachRunningSession(rs)
+			}
+			return fmt.Errorf("%s is already running (pid %d), use -attach to wait for it to exit or -new-instance to run another instance", rs.Id, rs.Pid)
+		}
+
+		if runLocks == nil {
+			return fmt.Errorf("%s or another product with the same prefix is already starting, use -new-instance to run another instance", id)
+		}
+	}
+
 	if err = setLastRunDate(rdx, id); err != nil {
 		return err
 	}
@@ -144,17 +193,61 @@ func Run(id string, request *InstallInfo, et *execTask) error {
 		return err
 	}
 
-	if err = osExec(id, ii.OperatingSystem, et); err != nil {
+	if et.absLogPath, err = newRunLog(id, runLogKindRun, rdx); err != nil {
+		return err
+	}
+
+	if et.protonLog {
+		var env []string
+		if env, err = protonLogEnv(id, rdx); err != nil {
+			return err
+		}
+		et.env = append(et.env, env...)
+	}
+
+	hooksEnv, err := launchHooksEnv(id, ii, et, rdx)
+	if err != nil {
+		return err
+	}
+
+	if err = runLaunchHooks(launchHookPreLaunch, et.preHooks, hooksEnv, et.verbose); err != nil {
+		return err
+	}
+
+	et.session = newRunningSession(id, ii, et)
+
+	execErr := osExec(id, ii.OperatingSystem, et)
+
+	// launched command might exit before the game does, e.g. launchers and umu-run
+	playSessionEnd := waitPlaySession(et)
+
+	if err = removeRunningSession(et.session); err != nil {
 		return err
 	}
 
-	playSessionDuration := time.Since(playSessionStart)
+	if et.protonLog {
+		if err = renameProtonLogs(et.absLogPath); err != nil {
+			return err
+		}
+	}
+
+	hooksEnv = append(hooksEnv, launchHookExitStatusEnv+"="+strconv.Itoa(exitStatus(execErr)))
+
+	// post-exit hooks run even if the product failed, e.g. to restore changes made by pre-launch hooks
+	postHooksErr := runLaunchHooks(launchHookPostExit, et.postHooks, hooksEnv, et.verbose)
+
+	// play session is recorded regardless of the exit status, e.g. for products that exit with an error on quit
+	playSessionDuration := playSessionEnd.Sub(playSessionStart)
 
 	if err = recordPlaytime(rdx, id, playSessionDuration); err != nil {
 		return err
 	}
 
-	return updateTotalPlaytime(rdx, id)
+	if err = updateTotalPlaytime(rdx, id); err != nil {
+		return err
+	}
+
+	return errors.Join(execErr, postHooksErr)
 }
 
 func installedIdFromNameFragment(name string, rdx redux.Readable) (string, error) {
@@ -425,7 +518,10 @@ func osApplyLaunchOptions(id string, ii *InstallInfo, et *execTask, rdx redux.Re
 	if err := rdx.MustHave(
 		data.LaunchOptionsExeProperty,
 		data.LaunchOptionsArgProperty,
-		data.LaunchOptionsEnvProperty); err != nil {
+		data.LaunchOptionsEnvProperty,
+		data.LaunchOptionsPreHookProperty,
+		data.LaunchOptionsPostHookProperty,
+		data.LaunchOptionsWrapperProperty); err != nil {
 		return err
 	}
 
@@ -443,5 +539,18 @@ func osApplyLaunchOptions(id string, ii *InstallInfo, et *execTask, rdx redux.Re
 		et.env = append(et.env, env...)
 	}
 
+	// global hooks run before product hooks, global wrappers are prepended before product wrappers
+	for _, key := range []string{data.GlobalLaunchOptionsKey, appOsLangCode} {
+		if preHooks, ok := rdx.GetAllValues(data.LaunchOptionsPreHookProperty, key); ok && len(preHooks) > 0 {
+			et.preHooks = append(et.preHooks, preHooks...)
+		}
+		if postHooks, ok := rdx.GetAllValues(data.LaunchOptionsPostHookProperty, key); ok && len(postHooks) > 0 {
+			et.postHooks = append(et.postHooks, postHooks...)
+		}
+		if wrappers, ok := rdx.GetAllValues(data.LaunchOptionsWrapperProperty, key); ok && len(wrappers) > 0 {
+			et.wrappers = append(et.wrappers, wrappers...)
+		}
+	}
+
 	return nil
 }
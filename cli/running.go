@@ -0,0 +1,349 @@
+package cli
+
+import (
+	"crypto/sha256"
+	"encoding/json/v2"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/arelate/southern_light/vangogh_integration"
+	"github.com/arelate/theo/data"
+	"github.com/boggydigital/camino"
+	"github.com/boggydigital/kevlar"
+	"github.com/boggydigital/nod"
+	"github.com/boggydigital/redux"
+)
+
+const (
+	runningSessionTempExt = ".tmp"
+	runLockExt            = ".lock"
+)
+
+// runningSession is stored as a file while Run is active, so that other theo processes
+// can list and stop running products. Session files are named by product id and theo pid,
+// so that multiple instances of the same product are recorded separately
+type runningSession struct {
+	Id              string                              `json:"id"`
+	OperatingSystem vangogh_integration.OperatingSystem `json:"os"`
+	LangCode        string                              `json:"lang-code"`
+	TheoPid         int                                 `json:"theo-pid"`
+	Pid             int                                 `json:"pid"`
+	Pgid            int                                 `json:"pgid"`
+	Prefix          string                              `json:"prefix,omitempty"`
+	Start           string                              `json:"start"`
+	absPath         string
+	file            *os.File
+}
+
+// active reports whether the session theo process or any of the session processes are still running.
+// Session file stays locked while the theo process that recorded it is running, unlike pids
+// that might be reused by unrelated processes. Sessions are not active when theo was terminated
+// and launched processes exited
+func (rs *runningSession) active() bool {
+	return fileLocked(rs.absPath) ||
+		(rs.Pgid > 0 && processGroupRunning(rs.Pgid)) ||
+		len(prefixWineserverPids(rs.Prefix)) > 0
+}
+
+func (rs *runningSession) String() string {
+
+	parts := []string{data.OsLangCode(rs.OperatingSystem, rs.LangCode)}
+
+	if start, err := time.Parse(time.RFC3339, rs.Start); err == nil {
+		parts = append(parts, fmt.Sprintf("started: %s (%s ago)",
+			start.Local().Format(time.DateTime), time.Since(start).Round(time.Second)))
+	}
+
+	parts = append(parts, fmt.Sprintf("pid: %d", rs.Pid), fmt.Sprintf("pgid: %d", rs.Pgid), fmt.Sprintf("theo pid: %d", rs.TheoPid))
+
+	if rs.Prefix != "" {
+		parts = append(parts, "prefix: "+rs.Prefix)
+	}
+
+	return strings.Join(parts, "; ")
+}
+
+func RunningHandler(_ *url.URL) error {
+	return Running()
+}
+
+func Running() error {
+
+	ra := nod.Begin("listing running products...")
+	defer ra.Done()
+
+	rdx, err := redux.NewReader(vangogh_integration.AbsReduxDir(), data.AllProperties()...)
+	if err != nil {
+		return err
+	}
+
+	sessions, err := readRunningSessions()
+	if err != nil {
+		return err
+	}
+
+	if len(sessions) == 0 {
+		ra.EndWithResult("found nothing")
+		return nil
+	}
+
+	summary := make(map[string][]string)
+
+	for _, rs := range sessions {
+		heading := rs.Id
+		if title, err := data.GetTitleProperty(rs.Id, rdx); err == nil {
+			heading += " " + title
+		}
+		summary[heading] = append(summary[heading], rs.String())
+	}
+
+	ra.EndWithSummary("found the following running products:", summary)
+
+	return nil
+}
+
+func newRunningSession(id string, ii *InstallInfo, et *execTask) *runningSession {
+	return &runningSession{
+		Id:              id,
+		OperatingSystem: ii.OperatingSystem,
+		LangCode:        ii.LangCode,
+		TheoPid:         os.Getpid(),
+		Prefix:          et.prefix,
+		Start:           time.Now().UTC().Format(time.RFC3339),
+		absPath:         absRunningSessionPath(id, os.Getpid()),
+	}
+}
+
+func absRunningSessionPath(id string, theoPid int) string {
+	return filepath.Join(data.AbsRunningSessionsDir(), fmt.Sprintf("%s-%d%s", id, theoPid, kevlar.JsonExt))
+}
+
+// writeRunningSession records the session and keeps the session file locked until the session
+// is removed or theo exits. The session is written to a temporary file that is only renamed
+// when complete and locked, so that other theo processes never read a partial session
+func writeRunningSession(rs *runningSession) error {
+
+	absRunningSessionsDir := data.AbsRunningSessionsDir()
+	if _, err := os.Stat(absRunningSessionsDir); os.IsNotExist(err) {
+		if err = os.MkdirAll(absRunningSessionsDir, camino.DefaultFileMode); err != nil {
+			return err
+		}
+	}
+
+	absTempPath := rs.absPath + runningSessionTempExt
+
+	runningSessionFile, err := os.Create(absTempPath)
+	if err != nil {
+		return err
+	}
+
+	if _, err = tryLockFile(runningSessionFile); err != nil {
+		return errors.Join(err, runningSessionFile.Close())
+	}
+
+	if err = json.MarshalWrite(runningSessionFile, rs); err != nil {
+		return errors.Join(err, runningSessionFile.Close())
+	}
+
+	if err = os.Rename(absTempPath, rs.absPath); err != nil {
+		return errors.Join(err, runningSessionFile.Close())
+	}
+
+	// sessions are rewritten with the same path, e.g. when a command is started again
+	var closeErr error
+	if rs.file != nil {
+		closeErr = rs.file.Close()
+	}
+
+	rs.file = runningSessionFile
+
+	return closeErr
+}
+
+// readRunningSession returns the running session recorded in the file, if it's active.
+// Inactive sessions are left by terminated theo processes and are removed
+func readRunningSession(absRunningSessionPath string) (*runningSession, error) {
+
+	runningSessionFile, err := os.Open(absRunningSessionPath)
+	if os.IsNotExist(err) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+	defer runningSessionFile.Close()
+
+	var rs runningSession
+	if err = json.UnmarshalRead(runningSessionFile, &rs); err != nil {
+		return nil, err
+	}
+
+	rs.absPath = absRunningSessionPath
+
+	if !rs.active() {
+		return nil, removeRunningSession(&rs)
+	}
+
+	return &rs, nil
+}
+
+// readRunningSessions returns all active running sessions
+func readRunningSessions() ([]*runningSession, error) {
+
+	absRunningSessionsDir := data.AbsRunningSessionsDir()
+	if _, err := os.Stat(absRunningSessionsDir); os.IsNotExist(err) {
+		return nil, nil
+	}
+
+	entries, err := os.ReadDir(absRunningSessionsDir)
+	if err != nil {
+		return nil, err
+	}
+
+	sessions := make([]*runningSession, 0, len(entries))
+
+	for _, entry := range entries {
+
+		if entry.IsDir() {
+			continue
+		}
+
+		absPath := filepath.Join(absRunningSessionsDir, entry.Name())
+
+		// temporary session files are only unlocked when theo was terminated while writing them
+		if strings.HasSuffix(entry.Name(), runningSessionTempExt) && !fileLocked(absPath) {
+			if err = os.Remove(absPath); err != nil && !os.IsNotExist(err) {
+				return nil, err
+			}
+			continue
+		}
+
+		if !strings.HasSuffix(entry.Name(), kevlar.JsonExt) {
+			continue
+		}
+
+		rs, err := readRunningSession(absPath)
+		if err != nil {
+			return nil, err
+		}
+
+		if rs != nil {
+			sessions = append(sessions, rs)
+		}
+	}
+
+	return sessions, nil
+}
+
+// readProductRunningSessions returns all active running sessions of the product,
+// there can be more than one when running new instances
+func readProductRunningSessions(id string) ([]*runningSession, error) {
+
+	sessions, err := readRunningSessions()
+	if err != nil {
+		return nil, err
+	}
+
+	return slices.DeleteFunc(sessions, func(rs *runningSession) bool { return rs.Id != id }), nil
+}
+
+// lockRun takes exclusive locks for the product and its prefix, so that only one theo process
+// can run the product or another product with the same prefix. Locks are held until unlockRun
+// or until theo exits. No locks are returned when any of them is held by another theo process
+func lockRun(id, absPrefixDir string) ([]*os.File, error) {
+
+	absRunningSessionsDir := data.AbsRunningSessionsDir()
+	if _, err := os.Stat(absRunningSessionsDir); os.IsNotExist(err) {
+		if err = os.MkdirAll(absRunningSessionsDir, camino.DefaultFileMode); err != nil {
+			return nil, err
+		}
+	}
+
+	// lock files are never removed, as removing them would allow another process to lock a new file
+	// while the removed one is still locked
+	lockFilenames := []string{
+		id + runLockExt,
+		fmt.Sprintf("prefix-%x%s", sha256.Sum256([]byte(absPrefixDir)), runLockExt),
+	}
+
+	locks := make([]*os.File, 0, len(lockFilenames))
+
+	for _, lockFilename := range lockFilenames {
+
+		lockFile, err := os.OpenFile(filepath.Join(absRunningSessionsDir, lockFilename), os.O_CREATE|os.O_RDWR, 0644)
+		if err != nil {
+			return nil, errors.Join(err, unlockRun(locks))
+		}
+
+		if locked, err := tryLockFile(lockFile); err != nil || !locked {
+			return nil, errors.Join(err, lockFile.Close(), unlockRun(locks))
+		}
+
+		locks = append(locks, lockFile)
+	}
+
+	return locks, nil
+}
+
+func unlockRun(locks []*os.File) error {
+
+	errs := make([]error, 0, len(locks))
+	for _, lockFile := range locks {
+		errs = append(errs, lockFile.Close())
+	}
+
+	return errors.Join(errs...)
+}
+
+// findRunningSession returns an active session of the product, or another product using the same prefix
+func findRunningSession(id, absPrefixDir string) (*runningSession, error) {
+
+	sessions, err := readRunningSessions()
+	if err != nil {
+		return nil, err
+	}
+
+	for _, rs := range sessions {
+		if rs.Id == id || (rs.Prefix != "" && rs.Prefix == absPrefixDir) {
+			return rs, nil
+		}
+	}
+
+	return nil, nil
+}
+
+// attachRunningSession waits for the running session to end. Playtime is recorded
+// by the theo process that started the session
+func attachRunningSession(rs *runningSession) error {
+
+	arsa := nod.Begin(" attached to running %s (pid %d), waiting for it to exit...", rs.Id, rs.Pid)
+	defer arsa.Done()
+
+	for rs.active() {
+		time.Sleep(playSessionPollInterval)
+	}
+
+	return nil
+}
+
+// removeRunningSession releases the session file lock, when the session was recorded
+// by this theo process, and removes the session file
+func removeRunningSession(rs *runningSession) error {
+
+	var closeErr error
+	if rs.file != nil {
+		closeErr = rs.file.Close()
+		rs.file = nil
+	}
+
+	if err := os.Remove(rs.absPath); err != nil && !os.IsNotExist(err) {
+		return errors.Join(err, closeErr)
+	}
+
+	return closeErr
+}
@@ -0,0 +1,467 @@
+package cli
+
+import (
+	"encoding/json/v2"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/arelate/southern_light/vangogh_integration"
+	"github.com/arelate/theo/data"
+	"github.com/boggydigital/camino"
+	"github.com/boggydigital/kevlar"
+	"github.com/boggydigital/nod"
+	"github.com/boggydigital/redux"
+)
+
+const defaultSnapshotsLimit = 1
+
+const (
+	relSnapshotInstalledDir        = "installed"
+	relSnapshotInventoryDir        = "inventory"
+	relSnapshotInstallInfoFilename = "install-info.json"
+)
+
+type snapshot struct {
+	absDir      string
+	created     time.Time
+	installInfo *InstallInfo
+}
+
+func RollbackHandler(u *url.URL) error {
+
+	q := u.Query()
+
+	id := q.Get(vangogh_integration.UrlIdParameter)
+
+	operatingSystem := vangogh_integration.AnyOperatingSystem
+	if q.Has(vangogh_integration.UrlOperatingSystemParameter) {
+		operatingSystem = vangogh_integration.ParseOperatingSystem(q.Get(vangogh_integration.UrlOperatingSystemParameter))
+	}
+
+	var langCode string
+	if q.Has(vangogh_integration.UrlLanguageCodeParameter) {
+		langCode = q.Get(vangogh_integration.UrlLanguageCodeParameter)
+	}
+
+	ii := &InstallInfo{
+		OperatingSystem: operatingSystem,
+		LangCode:        langCode,
+		Origin:          data.UnknownOrigin,
+	}
+
+	list := q.Has(vangogh_integration.UrlListParameter)
+
+	return Rollback(id, ii, list)
+}
+
+func Rollback(id string, request *InstallInfo, list bool) error {
+
+	ra := nod.Begin("rolling back %s...", id)
+	defer ra.Done()
+
+	rdx, err := redux.NewWriter(vangogh_integration.AbsReduxDir(), data.AllProperties()...)
+	if err != nil {
+		return err
+	}
+
+	installedInfo, err := matchInstalledInfo(id, request, rdx)
+	if err != nil {
+		return err
+	}
+
+	snapshots, err := readSnapshots(id, installedInfo, rdx)
+	if err != nil {
+		return err
+	}
+
+	if list {
+		return listSnapshots(id, snapshots)
+	}
+
+	if len(snapshots) == 0 {
+		return errors.New("no snapshots available for " + id)
+	}
+
+	// snapshots are sorted from the newest to the oldest
+	return restoreSnapshot(id, installedInfo, snapshots[0], rdx)
+}
+
+func snapshotsOsLangCodePfx(ii *InstallInfo) string {
+	return data.OsLangCode(ii.OperatingSystem, ii.LangCode) + "-"
+}
+
+// productInventoryFiles returns all inventory files for a product: main, DLCs and checksums
+func productInventoryFiles(id string, ii *InstallInfo, rdx redux.Readable) ([]string, error) {
+
+	absInventoryFilename, err := data.AbsInventoryFilename(id, ii.LangCode, ii.OperatingSystem, rdx)
+	if err != nil {
+		return nil, err
+	}
+
+	absChecksumsFilename, err := data.AbsInventoryChecksumsFilename(id, ii.LangCode, ii.OperatingSystem, rdx)
+	if err != nil {
+		return nil, err
+	}
+
+	absDlcInventoryPfx := strings.TrimSuffix(absInventoryFilename, kevlar.JsonExt) + "-dlc-"
+
+	absInventoryDir, _ := filepath.Split(absInventoryFilename)
+
+	entries, err := os.ReadDir(absInventoryDir)
+	if os.IsNotExist(err) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	inventoryFiles := make([]string, 0)
+
+	for _, entry := range entries {
+		absPath := filepath.Join(absInventoryDir, entry.Name())
+		if absPath == absInventoryFilename ||
+			absPath == absChecksumsFilename ||
+			strings.HasPrefix(absPath, absDlcInventoryPfx) {
+			inventoryFiles = append(inventoryFiles, absPath)
+		}
+	}
+
+	return inventoryFiles, nil
+}
+
+func createSnapshot(id string, ii *InstallInfo, limit int, rdx redux.Readable) error {
+
+	csa := nod.Begin(" creating snapshot of %s %s-%s...", id, ii.OperatingSystem, ii.LangCode)
+	defer csa.Done()
+
+	absInstalledDir, err := originOsInstalledPath(id, ii, rdx)
+	if err != nil {
+		return err
+	}
+
+	if _, err = os.Stat(absInstalledDir); os.IsNotExist(err) {
+		csa.EndWithResult("installed dir not present")
+		return nil
+	}
+
+	installedSize, err := dirSize(absInstalledDir)
+	if err != nil {
+		return err
+	}
+
+	absSnapshotsDir, err := data.AbsSnapshotsDir(id, rdx)
+	if err != nil {
+		return err
+	}
+
+	if ok, err := hasFreeSpaceForBytes(camino.GetAbs(vangogh_integration.Backups), installedSize); err != nil {
+		return err
+	} else if !ok && !ii.force {
+		return fmt.Errorf("not enough space to snapshot %s", id)
+	}
+
+	absSnapshotDir := filepath.Join(absSnapshotsDir, snapshotsOsLangCodePfx(ii)+time.Now().Format(camino.Layout))
+
+	if err = copyDir(absInstalledDir, filepath.Join(absSnapshotDir, relSnapshotInstalledDir)); err != nil {
+		return err
+	}
+
+	inventoryFiles, err := productInventoryFiles(id, ii, rdx)
+	if err != nil {
+		return err
+	}
+
+	absSnapshotInventoryDir := filepath.Join(absSnapshotDir, relSnapshotInventoryDir)
+	if err = os.MkdirAll(absSnapshotInventoryDir, camino.DefaultFileMode); err != nil {
+		return err
+	}
+
+	for _, absInventoryFile := range inventoryFiles {
+		_, filename := filepath.Split(absInventoryFile)
+		if err = copyFile(absInventoryFile, filepath.Join(absSnapshotInventoryDir, filename), 0644); err != nil {
+			return err
+		}
+	}
+
+	installInfoFile, err := os.Create(filepath.Join(absSnapshotDir, relSnapshotInstallInfoFilename))
+	if err != nil {
+		return err
+	}
+	defer installInfoFile.Close()
+
+	if err = json.MarshalWrite(installInfoFile, ii); err != nil {
+		return err
+	}
+
+	if err = cleanupSnapshots(id, ii, limit, rdx); err != nil {
+		return err
+	}
+
+	totalSize, err := dirSize(absSnapshotsDir)
+	if err != nil {
+		return err
+	}
+
+	csa.EndWithResult("snapshot size: %s, all %s snapshots: %s",
+		vangogh_integration.FormatBytes(installedSize),
+		id,
+		vangogh_integration.FormatBytes(totalSize))
+
+	return nil
+}
+
+// readSnapshots returns product snapshots for install info operating system and language code,
+// sorted from the newest to the oldest
+func readSnapshots(id string, ii *InstallInfo, rdx redux.Readable) ([]*snapshot, error) {
+
+	absSnapshotsDir, err := data.AbsSnapshotsDir(id, rdx)
+	if err != nil {
+		return nil, err
+	}
+
+	entries, err := os.ReadDir(absSnapshotsDir)
+	if os.IsNotExist(err) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	snapshots := make([]*snapshot, 0)
+
+	for _, entry := range entries {
+
+		if !entry.IsDir() || !strings.HasPrefix(entry.Name(), snapshotsOsLangCodePfx(ii)) {
+			continue
+		}
+
+		created, err := time.ParseInLocation(camino.Layout, strings.TrimPrefix(entry.Name(), snapshotsOsLangCodePfx(ii)), time.Local)
+		if err != nil {
+			continue
+		}
+
+		absSnapshotDir := filepath.Join(absSnapshotsDir, entry.Name())
+
+		installInfo, err := readSnapshotInstallInfo(absSnapshotDir)
+		if err != nil {
+			return nil, err
+		}
+
+		snapshots = append(snapshots, &snapshot{
+			absDir:      absSnapshotDir,
+			created:     created,
+			installInfo: installInfo,
+		})
+	}
+
+	slices.SortFunc(snapshots, func(a, b *snapshot) int {
+		return b.created.Compare(a.created)
+	})
+
+	return snapshots, nil
+}
+
+func readSnapshotInstallInfo(absSnapshotDir string) (*InstallInfo, error) {
+
+	installInfoFile, err := os.Open(filepath.Join(absSnapshotDir, relSnapshotInstallInfoFilename))
+	if err != nil {
+		return nil, err
+	}
+	defer installInfoFile.Close()
+
+	var installInfo InstallInfo
+	if err = json.UnmarshalRead(installInfoFile, &installInfo); err != nil {
+		return nil, err
+	}
+
+	return &installInfo, nil
+}
+
+func cleanupSnapshots(id string, ii *InstallInfo, limit int, rdx redux.Readable) error {
+
+	snapshots, err := readSnapshots(id, ii, rdx)
+	if err != nil {
+		return err
+	}
+
+	if len(snapshots) <= limit {
+		return nil
+	}
+
+	csa := nod.Begin(" removing %d old snapshot(s)...", len(snapshots)-limit)
+	defer csa.Done()
+
+	for _, s := range snapshots[limit:] {
+		if err = os.RemoveAll(s.absDir); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func listSnapshots(id string, snapshots []*snapshot) error {
+
+	lsa := nod.Begin("listing snapshots for %s...", id)
+	defer lsa.Done()
+
+	if len(snapshots) == 0 {
+		lsa.EndWithResult("found nothing")
+		return nil
+	}
+
+	summary := make(map[string][]string)
+
+	var totalSize int64
+
+	for _, s := range snapshots {
+
+		size, err := dirSize(s.absDir)
+		if err != nil {
+			return err
+		}
+
+		totalSize += size
+
+		snapshotLine := s.created.Format(time.DateTime)
+
+		infoLines := make([]string, 0)
+		if s.installInfo.Version != "" {
+			infoLines = append(infoLines, "version: "+s.installInfo.Version)
+		}
+		infoLines = append(infoLines, "size: "+vangogh_integration.FormatBytes(size))
+
+		summary[snapshotLine] = append(summary[snapshotLine], strings.Join(infoLines, "; "))
+	}
+
+	lsa.EndWithSummary(fmt.Sprintf("found %d snapshot(s), total size: %s", len(snapshots), vangogh_integration.FormatBytes(totalSize)), summary)
+
+	return nil
+}
+
+// swapInstalledDir restores snapshot installed files into a sibling directory first and only then
+// swaps it with the current installed directory, so that a failed restore keeps the current installation
+func swapInstalledDir(absSnapshotInstalledDir, absInstalledDir string) error {
+
+	absRestoreDir := absInstalledDir + ".restore"
+	absReplacedDir := absInstalledDir + ".replaced"
+
+	// leftovers of an interrupted restore are not needed
+	for _, absDir := range []string{absRestoreDir, absReplacedDir} {
+		if err := os.RemoveAll(absDir); err != nil {
+			return err
+		}
+	}
+
+	// renaming is only possible within the same filesystem, falling back to copying otherwise
+	renamed := true
+	if err := os.Rename(absSnapshotInstalledDir, absRestoreDir); err != nil {
+		renamed = false
+		if err = copyDir(absSnapshotInstalledDir, absRestoreDir); err != nil {
+			return errors.Join(err, os.RemoveAll(absRestoreDir))
+		}
+	}
+
+	// snapshot files are returned to the snapshot, if the swap fails
+	undoRestore := func(err error) error {
+		if renamed {
+			return errors.Join(err, os.Rename(absRestoreDir, absSnapshotInstalledDir))
+		}
+		return errors.Join(err, os.RemoveAll(absRestoreDir))
+	}
+
+	var replaced bool
+	if _, err := os.Stat(absInstalledDir); err == nil {
+		if err = os.Rename(absInstalledDir, absReplacedDir); err != nil {
+			return undoRestore(err)
+		}
+		replaced = true
+	} else if !os.IsNotExist(err) {
+		return undoRestore(err)
+	}
+
+	if err := os.Rename(absRestoreDir, absInstalledDir); err != nil {
+		if replaced {
+			err = errors.Join(err, os.Rename(absReplacedDir, absInstalledDir))
+		}
+		return undoRestore(err)
+	}
+
+	return os.RemoveAll(absReplacedDir)
+}
+
+func restoreSnapshot(id string, ii *InstallInfo, s *snapshot, rdx redux.Writeable) error {
+
+	rsa := nod.Begin(" restoring %s snapshot from %s...", id, s.created.Format(time.DateTime))
+	defer rsa.Done()
+
+	start := time.Now()
+
+	absInstalledDir, err := originOsInstalledPath(id, ii, rdx)
+	if err != nil {
+		return err
+	}
+
+	absSnapshotInstalledDir := filepath.Join(s.absDir, relSnapshotInstalledDir)
+
+	if err = swapInstalledDir(absSnapshotInstalledDir, absInstalledDir); err != nil {
+		return err
+	}
+
+	inventoryFiles, err := productInventoryFiles(id, ii, rdx)
+	if err != nil {
+		return err
+	}
+
+	for _, absInventoryFile := range inventoryFiles {
+		if err = os.Remove(absInventoryFile); err != nil {
+			return err
+		}
+	}
+
+	absInventoryFilename, err := data.AbsInventoryFilename(id, ii.LangCode, ii.OperatingSystem, rdx)
+	if err != nil {
+		return err
+	}
+
+	absInventoryDir, _ := filepath.Split(absInventoryFilename)
+	absSnapshotInventoryDir := filepath.Join(s.absDir, relSnapshotInventoryDir)
+
+	if snapshotInventoryFiles, err := os.ReadDir(absSnapshotInventoryDir); err == nil {
+
+		if len(snapshotInventoryFiles) > 0 {
+			if err = os.MkdirAll(absInventoryDir, camino.DefaultFileMode); err != nil {
+				return err
+			}
+		}
+
+		for _, entry := range snapshotInventoryFiles {
+			if err = copyFile(filepath.Join(absSnapshotInventoryDir, entry.Name()), filepath.Join(absInventoryDir, entry.Name()), 0644); err != nil {
+				return err
+			}
+		}
+
+	} else if !os.IsNotExist(err) {
+		return err
+	}
+
+	if err = pinInstallInfo(id, s.installInfo, rdx); err != nil {
+		return err
+	}
+
+	if err = os.RemoveAll(s.absDir); err != nil {
+		return err
+	}
+
+	if err = recordHistoryEvent(id, historyEventRollback, s.installInfo, start, rdx); err != nil {
+		return err
+	}
+
+	rsa.EndWithResult("restored version %s", s.installInfo.Version)
+
+	return nil
+}
@@ -626,6 +626,51 @@ func removeSteamGridImages(loginUser string, shortcutId uint32) error {
 	return nil
 }
 
+func removeSteamLogoPosition(id string, rdx redux.Readable) error {
+
+	rslpa := nod.Begin(" removing Steam Grid logo position for %s...", id)
+	defer rslpa.Done()
+
+	ok, err := steamStateDirExist()
+	if err != nil {
+		return err
+	}
+
+	if !ok {
+		rslpa.EndWithResult("Steam state dir not found")
+		return nil
+	}
+
+	title, err := data.GetTitleProperty(id, rdx)
+	if err != nil {
+		return err
+	}
+
+	shortcutId := steam_integration.ShortcutAppId(title)
+
+	loginUsers, err := getSteamLoginUsers()
+	if err != nil {
+		return err
+	}
+
+	udhd, err := vangogh_integration.UserDataHomeDir()
+	if err != nil {
+		return err
+	}
+
+	for _, loginUser := range loginUsers {
+
+		absSteamGridPath := filepath.Join(udhd, "Steam", "userdata", loginUser, "config", "grid")
+		absLogoPositionFilename := filepath.Join(absSteamGridPath, steam_grid.LogoPositionFilename(shortcutId))
+
+		if err = removeFileIfExists(absLogoPositionFilename); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
 func defaultLogoPosition() *logoPosition {
 	return &logoPosition{
 		PinnedPosition: defaultPinnedPosition,
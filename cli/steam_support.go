@@ -1,13 +1,18 @@
 package cli
 
 import (
+	"bufio"
+	"bytes"
 	"errors"
+	"fmt"
+	"io"
 	"net/url"
 	"os"
 	"path/filepath"
 	"slices"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/arelate/southern_light/steam_grid"
@@ -26,6 +31,8 @@ const (
 	eaPlayPfx = "link2ea://launchgame/"
 )
 
+var steamCmdMtx sync.Mutex
+
 func steamGetAppInfoKv(steamAppId string, rdx redux.Writeable, force bool) (steam_vdf.ValveDataFile, error) {
 
 	steamAppInfoDir := vangogh_integration.AbsProductTypeDir(vangogh_integration.SteamAppInfo)
@@ -63,7 +70,10 @@ func steamFetchAppInfo(steamAppId string, kvSteamAppInfo kevlar.KeyValues, rdx r
 		return err
 	}
 
+	// SteamCMD instances share state and can't run concurrently
+	steamCmdMtx.Lock()
 	printedAppInfo, err := steamcmd.AppInfoPrint(absSteamCmdPath, steamAppId)
+	steamCmdMtx.Unlock()
 	if err != nil {
 		return err
 	}
@@ -130,42 +140,219 @@ func steamUpdateApp(steamAppId string, operatingSystem vangogh_integration.Opera
 	return steamcmd.AppUpdate(absSteamCmdPath, steamAppId, operatingSystem, steamAppInstallDir, steamUsername, false)
 }
 
-func steamValidateApp(steamAppId string, operatingSystem vangogh_integration.OperatingSystem, rdx redux.Readable) error {
+// steamValidateApp runs SteamCMD app_update with validation and converts SteamCMD output
+// into validation results. SteamCMD output is still printed to allow Steam Guard prompts
+func steamValidateApp(steamAppId string, operatingSystem vangogh_integration.OperatingSystem, rdx redux.Readable) ([]ValidationResult, error) {
 
 	var steamAppName string
 	if san, ok := rdx.GetLastVal(vangogh_integration.SteamTitleProperty, steamAppId); ok && san != "" {
 		steamAppName = san
 	} else {
-		return errors.New("cannot resolve Steam app title")
+		return nil, errors.New("cannot resolve Steam app title")
 	}
 
 	var steamUsername string
 	if sun, ok := rdx.GetLastVal(data.SteamUsernameProperty, data.SteamUsernameProperty); ok && sun != "" {
 		steamUsername = sun
 	} else {
-		return errors.New("cannot resolve Steam username")
+		return nil, errors.New("cannot resolve Steam username")
 	}
 
-	scaua := nod.Begin("updating and verifying %s (%s) for %s with SteamCMD, please wait...", steamAppName, steamAppId, operatingSystem)
-	defer scaua.Done()
+	scvaa := nod.Begin("verifying %s (%s) for %s with SteamCMD, please wait...", steamAppName, steamAppId, operatingSystem)
+	defer scvaa.Done()
 
 	steamAppInstallDir, err := data.AbsSteamAppInstallDir(steamAppId, operatingSystem, rdx)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
 	if _, err = os.Stat(steamAppInstallDir); os.IsNotExist(err) {
-		if err = os.MkdirAll(steamAppInstallDir, camino.DefaultFileMode); err != nil {
-			return err
-		}
+		return []ValidationResult{ValResFileNotFound}, nil
 	}
 
 	absSteamCmdPath, err := data.AbsSteamCmdBinPath(vangogh_integration.CurrentOs())
+	if err != nil {
+		return nil, err
+	}
+
+	var results []ValidationResult
+
+	// steamcmd.AppUpdate prints SteamCMD output to stdout, which is captured
+	// to be parsed while still being printed
+	output, err := captureStdout(func() error {
+		return steamcmd.AppUpdate(absSteamCmdPath, steamAppId, operatingSystem, steamAppInstallDir, steamUsername, true)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("SteamCMD validation of %s failed: %w", steamAppId, err)
+	}
+
+	if results, err = parseSteamCmdValidationOutput(output); err != nil {
+		return nil, err
+	}
+
+	return results, nil
+}
+
+// captureStdout runs a function while copying everything written to stdout into a buffer
+func captureStdout(fn func() error) (*bytes.Buffer, error) {
+
+	pr, pw, err := os.Pipe()
+	if err != nil {
+		return nil, err
+	}
+
+	stdout := os.Stdout
+	os.Stdout = pw
+
+	output := bytes.NewBuffer(nil)
+	copied := make(chan error)
+
+	go func() {
+		_, copyErr := io.Copy(io.MultiWriter(stdout, output), pr)
+		copied <- copyErr
+	}()
+
+	fnErr := fn()
+
+	os.Stdout = stdout
+	pw.Close()
+
+	copyErr := <-copied
+	pr.Close()
+
+	if fnErr != nil {
+		return output, fnErr
+	}
+
+	return output, copyErr
+}
+
+// parseSteamCmdValidationOutput maps SteamCMD app_update -validate output lines to validation results:
+// content downloaded after verification means mismatched files were fixed,
+// Success! means all files are valid, Error! means validation failed
+func parseSteamCmdValidationOutput(output io.Reader) ([]ValidationResult, error) {
+
+	results := make([]ValidationResult, 0)
+
+	verifying := false
+	scanner := bufio.NewScanner(output)
+
+	for scanner.Scan() {
+
+		line := strings.TrimSpace(scanner.Text())
+		lowerLine := strings.ToLower(line)
+
+		switch {
+		case strings.HasPrefix(lowerLine, "update state") && strings.Contains(lowerLine, "verifying"):
+			verifying = true
+		case strings.HasPrefix(lowerLine, "update state") && strings.Contains(lowerLine, "downloading"):
+			if verifying && !slices.Contains(results, ValResMismatch) {
+				nod.Log("SteamCMD downloaded content after verification: %s", line)
+				results = append(results, ValResMismatch)
+			}
+		case strings.HasPrefix(lowerLine, "success!"):
+			results = append(results, ValResValid)
+		case strings.HasPrefix(line, "Error!") || strings.HasPrefix(line, "ERROR!"):
+			nod.Log("SteamCMD validation error: %s", line)
+			results = append(results, ValResError)
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	if len(results) == 0 {
+		results = append(results, ValResError)
+	}
+
+	return results, nil
+}
+
+func steamInstalledBuildId(steamAppId string, operatingSystem vangogh_integration.OperatingSystem, rdx redux.Readable) (string, error) {
+
+	steamAppInstallDir, err := data.AbsSteamAppInstallDir(steamAppId, operatingSystem, rdx)
+	if err != nil {
+		return "", err
+	}
+
+	absAppManifestPath := filepath.Join(steamAppInstallDir, "steamapps", "appmanifest_"+steamAppId+".acf")
+
+	appManifestFile, err := os.Open(absAppManifestPath)
+	if os.IsNotExist(err) {
+		return "", nil
+	} else if err != nil {
+		return "", err
+	}
+	defer appManifestFile.Close()
+
+	appManifestKv, err := steam_vdf.ReadText(appManifestFile)
+	if err != nil {
+		return "", err
+	}
+
+	if buildId, ok := appManifestKv.Val("AppState", "buildid"); ok {
+		return buildId, nil
+	}
+
+	return "", nil
+}
+
+func steamValidateInstalled(steamAppId string, request *InstallInfo, originData *data.OriginData, rdx redux.Writeable) error {
+
+	svia := nod.Begin("validating installed Steam app %s...", steamAppId)
+	defer svia.Done()
+
+	installedInfo, err := matchInstalledInfo(steamAppId, request, rdx)
 	if err != nil {
 		return err
 	}
 
-	return steamcmd.AppUpdate(absSteamCmdPath, steamAppId, operatingSystem, steamAppInstallDir, steamUsername, true)
+	latestBuildId, err := steamAppInfoVersion(steamAppId, originData.AppInfoKv)
+	if err != nil {
+		return err
+	}
+
+	installedBuildId, err := steamInstalledBuildId(steamAppId, installedInfo.OperatingSystem, rdx)
+	if err != nil {
+		return err
+	}
+
+	if installedBuildId == "" {
+		installedBuildId = installedInfo.Version
+	}
+
+	// SteamCMD always validates the latest build, so validating an outdated installation would update it
+	if installedBuildId != latestBuildId {
+		return fmt.Errorf("installed build %s differs from the latest build %s, "+
+			"validation would update %s, use update first", installedBuildId, latestBuildId, steamAppId)
+	}
+
+	results, err := steamValidateApp(steamAppId, installedInfo.OperatingSystem, rdx)
+	if err != nil {
+		return err
+	}
+
+	validatedBuildId, err := steamInstalledBuildId(steamAppId, installedInfo.OperatingSystem, rdx)
+	if err != nil {
+		return err
+	}
+
+	if validatedBuildId != "" && validatedBuildId != installedInfo.Version {
+		nod.Log("Steam app %s build changed from %s to %s during validation", steamAppId, installedInfo.Version, validatedBuildId)
+		installedInfo.Version = validatedBuildId
+		if err = pinInstallInfo(steamAppId, installedInfo, rdx); err != nil {
+			return err
+		}
+	}
+
+	svia.EndWithResult("%s", summarizeValidationResults(results))
+
+	if slices.Contains(results, ValResError) || slices.Contains(results, ValResFileNotFound) {
+		return errors.New("SteamCMD validation failed for " + steamAppId)
+	}
+
+	return nil
 }
 
 func steamReduceAppInfo(steamAppId string, appInfoKv steam_vdf.ValveDataFile, rdx redux.Writeable) error {
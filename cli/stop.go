@@ -0,0 +1,93 @@
+package cli
+
+import (
+	"net/url"
+	"time"
+
+	"github.com/arelate/southern_light/vangogh_integration"
+	"github.com/boggydigital/nod"
+)
+
+const (
+	// stopTimeout is the time processes have to exit gracefully before they're killed
+	stopTimeout      = 10 * time.Second
+	stopPollInterval = 500 * time.Millisecond
+)
+
+func StopHandler(u *url.URL) error {
+
+	q := u.Query()
+
+	id := q.Get(vangogh_integration.UrlIdParameter)
+
+	return Stop(id)
+}
+
+// Stop terminates running product processes and the prefix wineserver, first gracefully
+// and then forcefully. Run that launched the product records playtime as usual
+func Stop(id string) error {
+
+	sa := nod.Begin("stopping %s...", id)
+	defer sa.Done()
+
+	sessions, err := readProductRunningSessions(id)
+	if err != nil {
+		return err
+	}
+
+	if len(sessions) == 0 {
+		sa.EndWithResult("%s is not running", id)
+		return nil
+	}
+
+	// all instances of the product are stopped
+	for _, rs := range sessions {
+		if err = stopRunningSession(rs); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func stopRunningSession(rs *runningSession) error {
+
+	if rs.Pgid > 0 {
+		if err := stopProcesses(
+			func() bool { return processGroupRunning(rs.Pgid) },
+			func(force bool) error { return signalProcessGroup(rs.Pgid, force) }); err != nil {
+			return err
+		}
+	}
+
+	// WINE processes exit when wineserver is stopped
+	for _, pid := range prefixWineserverPids(rs.Prefix) {
+		if err := stopProcesses(
+			func() bool { return processRunning(pid) },
+			func(force bool) error { return signalProcess(pid, force) }); err != nil {
+			return err
+		}
+	}
+
+	return removeRunningSession(rs)
+}
+
+// stopProcesses signals processes to terminate and waits for them to exit,
+// processes that are still running after the timeout are killed
+func stopProcesses(running func() bool, signal func(force bool) error) error {
+
+	if err := signal(false); err != nil {
+		return err
+	}
+
+	deadline := time.Now().Add(stopTimeout)
+
+	for time.Now().Before(deadline) {
+		if !running() {
+			return nil
+		}
+		time.Sleep(stopPollInterval)
+	}
+
+	return signal(true)
+}
@@ -0,0 +1,332 @@
+package cli
+
+import (
+	"cmp"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/arelate/southern_light/vangogh_integration"
+	"github.com/arelate/theo/data"
+	"github.com/boggydigital/camino"
+	"github.com/boggydigital/nod"
+	"github.com/boggydigital/redux"
+)
+
+type cleanupTarget int
+
+const (
+	cleanupTargetDownloads cleanupTarget = iota
+	cleanupTargetPrefix
+	cleanupTargetProduct
+)
+
+func (ct cleanupTarget) String() string {
+	switch ct {
+	case cleanupTargetDownloads:
+		return "downloads"
+	case cleanupTargetPrefix:
+		return "prefix"
+	case cleanupTargetProduct:
+		return "game"
+	default:
+		return "unknown"
+	}
+}
+
+type cleanupCandidate struct {
+	target      cleanupTarget
+	id          string
+	title       string
+	installInfo *InstallInfo
+	absPath     string
+	bytes       int64
+	idleDays    int
+	playtime    time.Duration
+	staleness   float64
+}
+
+func (cc *cleanupCandidate) String() string {
+
+	var name string
+	switch cc.target {
+	case cleanupTargetProduct:
+		name = fmt.Sprintf("%s %s %s (%s)", cc.target, cc.id, cc.title, data.OsLangCode(cc.installInfo.OperatingSystem, cc.installInfo.LangCode))
+	case cleanupTargetDownloads:
+		name = fmt.Sprintf("%s %s %s", cc.target, cc.id, cc.title)
+	default:
+		name = fmt.Sprintf("%s %s", cc.target, cc.absPath)
+	}
+
+	parts := []string{name, vangogh_integration.FormatBytes(cc.bytes)}
+
+	if cc.target == cleanupTargetProduct {
+		parts = append(parts, fmt.Sprintf("idle for %d day(s)", cc.idleDays))
+		if cc.playtime > 0 {
+			parts = append(parts, "played "+cc.playtime.String())
+		} else {
+			parts = append(parts, "never played")
+		}
+	}
+
+	return strings.Join(parts, "; ")
+}
+
+func SuggestCleanupHandler(u *url.URL) error {
+
+	q := u.Query()
+
+	freeBytes, err := parseBytes(q.Get(data.UrlFreeParameter))
+	if err != nil {
+		return err
+	}
+
+	apply := q.Has(data.UrlApplyParameter)
+
+	return SuggestCleanup(freeBytes, apply)
+}
+
+func SuggestCleanup(freeBytes int64, apply bool) error {
+
+	sca := nod.Begin("suggesting cleanup to free %s...", vangogh_integration.FormatBytes(freeBytes))
+	defer sca.Done()
+
+	rdx, err := redux.NewReader(vangogh_integration.AbsReduxDir(), data.AllProperties()...)
+	if err != nil {
+		return err
+	}
+
+	candidates, err := cleanupCandidates(rdx)
+	if err != nil {
+		return err
+	}
+
+	selected := make([]*cleanupCandidate, 0)
+	var selectedBytes int64
+
+	for _, cc := range candidates {
+		if selectedBytes >= freeBytes {
+			break
+		}
+		selected = append(selected, cc)
+		selectedBytes += cc.bytes
+	}
+
+	if len(selected) == 0 {
+		sca.EndWithResult("found nothing to clean up")
+		return nil
+	}
+
+	summary := make(map[string][]string)
+	for n, cc := range selected {
+		summary["suggested:"] = append(summary["suggested:"], fmt.Sprintf("%d. %s", n+1, cc))
+	}
+
+	if selectedBytes < freeBytes {
+		summary["suggested:"] = append(summary["suggested:"],
+			fmt.Sprintf("all candidates only free %s of %s", vangogh_integration.FormatBytes(selectedBytes), vangogh_integration.FormatBytes(freeBytes)))
+	}
+
+	if !apply {
+		sca.EndWithSummary(fmt.Sprintf("removing %d item(s) would free %s, use -apply to remove:", len(selected), vangogh_integration.FormatBytes(selectedBytes)), summary)
+		return nil
+	}
+
+	if err = applyCleanup(selected); err != nil {
+		return err
+	}
+
+	sca.EndWithSummary(fmt.Sprintf("freed %s:", vangogh_integration.FormatBytes(selectedBytes)), summary)
+
+	return nil
+}
+
+// cleanupCandidates returns all products, kept downloads and orphaned prefixes ranked
+// in the order they should be removed. Downloads and orphaned prefixes are not needed to play,
+// so they are suggested before any installed product
+func cleanupCandidates(rdx redux.Readable) ([]*cleanupCandidate, error) {
+
+	productsUsage, err := productsDiskUsage("", rdx)
+	if err != nil {
+		return nil, err
+	}
+
+	downloads := make([]*cleanupCandidate, 0)
+	products := make([]*cleanupCandidate, 0)
+
+	for _, pdu := range productsUsage {
+
+		product := &cleanupCandidate{
+			target:      cleanupTargetProduct,
+			id:          pdu.id,
+			title:       pdu.title,
+			installInfo: &pdu.installInfo,
+			bytes:       pdu.installedBytes,
+		}
+
+		product.idleDays, product.playtime = productActivity(pdu.id, rdx)
+		// games that are played a lot are less likely to be good cleanup candidates,
+		// even if they were not run recently
+		product.staleness = float64(product.idleDays) / (1 + product.playtime.Hours()/10)
+
+		products = append(products, product)
+
+		downloadsBytes := pdu.downloadsBytes + pdu.chunksBytes
+		if downloadsBytes == 0 {
+			continue
+		}
+
+		var absDownloadsDir string
+		switch pdu.installInfo.Origin {
+		case data.VangoghOrigin:
+			absDownloadsDir = filepath.Join(camino.GetAbs(vangogh_integration.Downloads), pdu.id)
+		case data.EpicGamesOrigin:
+			absDownloadsDir = data.AbsChunksDownloadDir(pdu.id, pdu.installInfo.OperatingSystem)
+		}
+
+		// vangogh downloads are shared by all installed operating systems and languages
+		if slices.ContainsFunc(downloads, func(cc *cleanupCandidate) bool { return cc.absPath == absDownloadsDir }) {
+			continue
+		}
+
+		downloads = append(downloads, &cleanupCandidate{
+			target:  cleanupTargetDownloads,
+			id:      pdu.id,
+			title:   pdu.title,
+			absPath: absDownloadsDir,
+			bytes:   downloadsBytes,
+		})
+	}
+
+	installedInfos, err := allInstalledInfos(rdx)
+	if err != nil {
+		return nil, err
+	}
+
+	prefixItems, err := gcPrefixesItems(installedInfos, rdx)
+	if err != nil {
+		return nil, err
+	}
+
+	prefixes := make([]*cleanupCandidate, 0, len(prefixItems))
+	for _, item := range prefixItems {
+		prefixes = append(prefixes, &cleanupCandidate{
+			target:  cleanupTargetPrefix,
+			absPath: item.absPath,
+			bytes:   item.size,
+		})
+	}
+
+	bySize := func(a, b *cleanupCandidate) int {
+		return cmp.Compare(b.bytes, a.bytes)
+	}
+
+	slices.SortFunc(downloads, bySize)
+	slices.SortFunc(prefixes, bySize)
+	slices.SortFunc(products, func(a, b *cleanupCandidate) int {
+		if c := cmp.Compare(b.staleness, a.staleness); c != 0 {
+			return c
+		}
+		return bySize(a, b)
+	})
+
+	candidates := make([]*cleanupCandidate, 0, len(downloads)+len(prefixes)+len(products))
+	candidates = append(candidates, downloads...)
+	candidates = append(candidates, prefixes...)
+	candidates = append(candidates, products...)
+
+	return candidates, nil
+}
+
+// productActivity returns number of days since the product was last run
+// (or installed, if it was never run) and total playtime
+func productActivity(id string, rdx redux.Readable) (int, time.Duration) {
+
+	var lastActive time.Time
+
+	for _, property := range []string{data.LastRunDateProperty, data.InstallDateProperty} {
+		if dts, ok := rdx.GetLastVal(property, id); ok && dts != "" {
+			if dt, err := time.Parse(time.RFC3339, dts); err == nil {
+				lastActive = dt
+				break
+			}
+		}
+	}
+
+	var idleDays int
+	if !lastActive.IsZero() {
+		idleDays = int(time.Since(lastActive).Hours() / 24)
+	}
+
+	var playtime time.Duration
+	if tpms, ok := rdx.GetLastVal(data.TotalPlaytimeMinutesProperty, id); ok && tpms != "" {
+		if minutes, err := strconv.ParseInt(tpms, 10, 64); err == nil {
+			playtime = time.Duration(minutes) * time.Minute
+		}
+	}
+
+	return idleDays, playtime
+}
+
+func applyCleanup(selected []*cleanupCandidate) error {
+
+	aca := nod.NewProgress("removing suggested items...")
+	defer aca.Done()
+
+	aca.TotalInt(len(selected))
+
+	for _, cc := range selected {
+
+		switch cc.target {
+		case cleanupTargetProduct:
+			request := &InstallInfo{
+				OperatingSystem: cc.installInfo.OperatingSystem,
+				LangCode:        cc.installInfo.LangCode,
+				Origin:          cc.installInfo.Origin,
+				force:           true,
+			}
+			if err := Uninstall(cc.id, request, false, false, false, false); err != nil {
+				return err
+			}
+		default:
+			if err := os.RemoveAll(cc.absPath); err != nil {
+				return err
+			}
+		}
+
+		aca.Increment()
+	}
+
+	return nil
+}
+
+// parseBytes parses sizes like "30G", "512MB" or "1.5T" using the same decimal units as FormatBytes
+func parseBytes(size string) (int64, error) {
+
+	s := strings.TrimSuffix(strings.ToUpper(strings.TrimSpace(size)), "B")
+
+	if s == "" {
+		return 0, errors.New("size is required, e.g. 30G")
+	}
+
+	multiplier := int64(1)
+	if unit := strings.IndexByte("KMGTPE", s[len(s)-1]); unit >= 0 {
+		for range unit + 1 {
+			multiplier *= 1000
+		}
+		s = strings.TrimSpace(s[:len(s)-1])
+	}
+
+	value, err := strconv.ParseFloat(s, 64)
+	if err != nil || value < 0 {
+		return 0, errors.New("invalid size: " + size)
+	}
+
+	return int64(value * float64(multiplier)), nil
+}
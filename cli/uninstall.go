@@ -1,13 +1,18 @@
 package cli
 
 import (
+	"fmt"
 	"net/url"
 	"os"
+	"path/filepath"
 	"strings"
+	"time"
 
 	"github.com/arelate/southern_light/steamcmd"
 	"github.com/arelate/southern_light/vangogh_integration"
 	"github.com/arelate/theo/data"
+	"github.com/boggydigital/camino"
+	"github.com/boggydigital/kevlar"
 	"github.com/boggydigital/nod"
 	"github.com/boggydigital/redux"
 )
@@ -36,21 +41,26 @@ func UninstallHandler(u *url.URL) error {
 	}
 
 	purge := q.Has(vangogh_integration.UrlPurgeParameter)
+	complete := q.Has(data.UrlCompleteParameter)
+	keepSaves := q.Has(data.UrlKeepSavesParameter)
+	dryRun := q.Has(data.UrlDryRunParameter)
 
-	return Uninstall(id, ii, purge)
+	return Uninstall(id, ii, purge, complete, keepSaves, dryRun)
 }
 
-func Uninstall(id string, request *InstallInfo, purge bool) error {
+func Uninstall(id string, request *InstallInfo, purge, complete, keepSaves, dryRun bool) error {
 
 	ua := nod.Begin("uninstalling %s...", id)
 	defer ua.Done()
 
+	start := time.Now()
+
 	rdx, err := redux.NewWriter(vangogh_integration.AbsReduxDir(), data.AllProperties()...)
 	if err != nil {
 		return err
 	}
 
-	if !request.force && !purge {
+	if !request.force && !purge && !dryRun {
 		ua.EndWithResult("uninstall requires force or purge parameter")
 		return nil
 	}
@@ -60,6 +70,10 @@ func Uninstall(id string, request *InstallInfo, purge bool) error {
 		return err
 	}
 
+	if dryRun {
+		return uninstallDryRun(id, installInfo, purge, rdx)
+	}
+
 	switch purge {
 	case true:
 		if err = originPurgeInstallation(id, installInfo, rdx); err != nil {
@@ -86,7 +100,13 @@ func Uninstall(id string, request *InstallInfo, purge bool) error {
 		return err
 	}
 
-	return nil
+	if complete {
+		if err = removeProductData(id, installInfo, keepSaves, rdx); err != nil {
+			return err
+		}
+	}
+
+	return recordHistoryEvent(id, historyEventUninstall, installInfo, start, rdx)
 }
 
 func originUninstall(id string, installInfo *InstallInfo, rdx redux.Writeable) error {
@@ -167,3 +187,292 @@ func originUninstallDlcs(id string, ii *InstallInfo, rdx redux.Writeable) error
 		return nil
 	}
 }
+
+// removeProductData removes product data that is kept by a regular uninstall: prefix, umu-launcher configs,
+// Steam Grid logo position, inventories, snapshots, extras and playtime. Most of that data is shared
+// by all product installations, so it's only removed when no other installations remain
+func removeProductData(id string, ii *InstallInfo, keepSaves bool, rdx redux.Writeable) error {
+
+	rpda := nod.Begin("removing %s product data...", id)
+	defer rpda.Done()
+
+	inventoryFiles, err := productInventoryFiles(id, ii, rdx)
+	if err != nil {
+		return err
+	}
+
+	for _, absInventoryFile := range inventoryFiles {
+		if err = os.Remove(absInventoryFile); err != nil {
+			return err
+		}
+	}
+
+	if rdx.HasKey(data.InstallInfoProperty, id) {
+		rpda.EndWithResult("other installations remain, keeping shared product data")
+		return nil
+	}
+
+	absPrefixDir, err := data.AbsPrefixDir(id, ii.Origin, rdx)
+	if err != nil {
+		return err
+	}
+
+	if keepSaves {
+		if err = backupPrefixSaves(id, absPrefixDir, rdx); err != nil {
+			return err
+		}
+	}
+
+	absProductDirs := []string{absPrefixDir}
+
+	if absSnapshotsDir, err := data.AbsSnapshotsDir(id, rdx); err == nil {
+		absProductDirs = append(absProductDirs, absSnapshotsDir)
+	} else {
+		return err
+	}
+
+	if ii.Origin == data.VangoghOrigin {
+		if absExtrasDir, err := data.AbsExtrasDir(id, rdx); err == nil {
+			absProductDirs = append(absProductDirs, absExtrasDir)
+		} else {
+			return err
+		}
+	}
+
+	absProductFiles, err := productDataFiles(id, rdx)
+	if err != nil {
+		return err
+	}
+
+	for _, absPath := range append(absProductDirs, absProductFiles...) {
+		if err = os.RemoveAll(absPath); err != nil {
+			return err
+		}
+	}
+
+	if err = removeSteamLogoPosition(id, rdx); err != nil {
+		return err
+	}
+
+	kvValidationCache, err := kevlar.New(data.AbsValidationCacheDir(), kevlar.JsonExt)
+	if err != nil {
+		return err
+	}
+
+	if kvValidationCache.Has(id) {
+		if err = kvValidationCache.Cut(id); err != nil {
+			return err
+		}
+	}
+
+	for _, property := range []string{
+		data.InstallDateProperty,
+		data.LastRunDateProperty,
+		data.PlaytimeMinutesProperty,
+		data.TotalPlaytimeMinutesProperty,
+		data.UpdateAvailableProperty,
+		data.UpdatePolicyProperty,
+	} {
+		if err = rdx.CutKeys(property, id); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// productDataFiles returns umu-launcher configs for all umu-launcher versions
+// and inventories for all operating systems and languages
+func productDataFiles(id string, rdx redux.Readable) ([]string, error) {
+
+	title, err := data.GetTitleProperty(id, rdx)
+	if err != nil {
+		return nil, err
+	}
+
+	inventoryDir := camino.GetRel(vangogh_integration.Inventory, vangogh_integration.InstalledApps)
+	umuConfigsDir := camino.GetRel(vangogh_integration.UmuConfigs, vangogh_integration.InstalledApps)
+
+	sanitizedTitle := camino.Sanitize(title)
+
+	patterns := []string{
+		filepath.Join(inventoryDir, "*", sanitizedTitle+kevlar.JsonExt),
+		filepath.Join(inventoryDir, "*", sanitizedTitle+"-checksums"+kevlar.JsonExt),
+		filepath.Join(inventoryDir, "*", sanitizedTitle+"-dlc-*"+kevlar.JsonExt),
+		filepath.Join(umuConfigsDir, "*", id+"-*.toml"),
+	}
+
+	absFiles := make([]string, 0)
+
+	for _, pattern := range patterns {
+		matches, err := filepath.Glob(pattern)
+		if err != nil {
+			return nil, err
+		}
+		absFiles = append(absFiles, matches...)
+	}
+
+	return absFiles, nil
+}
+
+// backupPrefixSaves copies prefix user directories, where games keep saves and settings,
+// to the product saves backup dir
+func backupPrefixSaves(id, absPrefixDir string, rdx redux.Readable) error {
+
+	bpsa := nod.Begin(" backing up %s saves...", id)
+	defer bpsa.Done()
+
+	absUsersDir := filepath.Join(absPrefixDir, prefixRelDriveCDir, prefixRelUsersDir)
+
+	if _, err := os.Stat(absUsersDir); os.IsNotExist(err) {
+		bpsa.EndWithResult("prefix user directories not present")
+		return nil
+	}
+
+	absSavesDir, err := data.AbsSavesDir(id, rdx)
+	if err != nil {
+		return err
+	}
+
+	absDstDir := filepath.Join(absSavesDir, time.Now().Format(camino.Layout), prefixRelUsersDir)
+
+	if err = copyDir(absUsersDir, absDstDir); err != nil {
+		return err
+	}
+
+	bpsa.EndWithResult("saved to %s", absDstDir)
+
+	return nil
+}
+
+// uninstallDryRun reports files that would be removed by uninstall and files that would be kept
+// in the installed directory, e.g. mods or configs added after installation
+func uninstallDryRun(id string, ii *InstallInfo, purge bool, rdx redux.Writeable) error {
+
+	udra := nod.Begin("checking files that uninstall would remove for %s %s...", id, data.OsLangCode(ii.OperatingSystem, ii.LangCode))
+	defer udra.Done()
+
+	absInstalledDir, err := originOsInstalledPath(id, ii, rdx)
+	if err != nil {
+		return err
+	}
+
+	if _, err = os.Stat(absInstalledDir); os.IsNotExist(err) {
+		udra.EndWithResult("installed directory not present: %s", absInstalledDir)
+		return nil
+	}
+
+	installedFiles, err := relWalkDir(absInstalledDir)
+	if err != nil {
+		return err
+	}
+
+	var uninstalledFiles []string
+	switch purge {
+	case true:
+		uninstalledFiles = installedFiles
+	default:
+		if uninstalledFiles, err = originUninstalledFiles(id, ii, installedFiles, rdx); err != nil {
+			return err
+		}
+	}
+
+	removedFiles := make([]string, 0, len(uninstalledFiles))
+	removed := make(map[string]any, len(uninstalledFiles))
+	var removedBytes int64
+
+	for _, relFile := range uninstalledFiles {
+		if _, ok := removed[relFile]; ok {
+			continue
+		}
+		if fi, err := os.Stat(filepath.Join(absInstalledDir, relFile)); err == nil {
+			removedFiles = append(removedFiles, relFile)
+			removed[relFile] = nil
+			removedBytes += fi.Size()
+		}
+	}
+
+	keptFiles := make([]string, 0)
+	var keptBytes int64
+
+	for _, relFile := range installedFiles {
+		if _, ok := removed[relFile]; ok {
+			continue
+		}
+		if fi, err := os.Stat(filepath.Join(absInstalledDir, relFile)); err == nil {
+			keptFiles = append(keptFiles, relFile)
+			keptBytes += fi.Size()
+		}
+	}
+
+	summary := make(map[string][]string)
+
+	removedHeading := fmt.Sprintf("would remove %d file(s), %s:", len(removedFiles), vangogh_integration.FormatBytes(removedBytes))
+	switch ii.verbose {
+	case true:
+		summary[removedHeading] = removedFiles
+	default:
+		summary[removedHeading] = []string{"use -verbose to list all removed files"}
+	}
+
+	if len(keptFiles) > 0 {
+		keptHeading := fmt.Sprintf("would keep %d file(s) not installed by %s, %s (use -purge to remove):", len(keptFiles), ii.Origin, vangogh_integration.FormatBytes(keptBytes))
+		summary[keptHeading] = keptFiles
+	}
+
+	udra.EndWithSummary(fmt.Sprintf("uninstall dry-run for %s in %s:", id, absInstalledDir), summary)
+
+	return nil
+}
+
+// originUninstalledFiles returns files that originUninstall and originUninstallDlcs would remove,
+// relative to the installed directory
+func originUninstalledFiles(id string, ii *InstallInfo, installedFiles []string, rdx redux.Writeable) ([]string, error) {
+
+	switch ii.Origin {
+	case data.VangoghOrigin:
+
+		inventoriedFiles, err := readInventory(id, ii, rdx)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, dlcId := range ii.DownloadableContent {
+			var dlcInventoriedFiles []string
+			if dlcInventoriedFiles, err = readDlcInventory(id, dlcId, ii, rdx); err != nil {
+				return nil, err
+			}
+			inventoriedFiles = append(inventoriedFiles, dlcInventoriedFiles...)
+		}
+
+		return inventoriedFiles, nil
+
+	case data.SteamOrigin:
+		// SteamCMD removes the whole app directory
+		return installedFiles, nil
+
+	case data.EpicGamesOrigin:
+
+		manifestFiles := make([]string, 0)
+
+		for _, appName := range append([]string{id}, ii.DownloadableContent...) {
+
+			// getting origin data sets install info defaults, use a copy to keep the installed info intact
+			iic := *ii
+
+			originData, err := originGetData(appName, &iic, rdx, false)
+			if err != nil {
+				return nil, err
+			}
+
+			for _, file := range originData.Manifest.FileList.List {
+				manifestFiles = append(manifestFiles, file.Filename)
+			}
+		}
+
+		return manifestFiles, nil
+
+	default:
+		return nil, ii.Origin.ErrUnsupportedOrigin()
+	}
+}
@@ -3,8 +3,15 @@ package cli
 import (
 	"encoding/json/v2"
 	"fmt"
+	"html"
+	"maps"
 	"net/url"
+	"regexp"
+	"slices"
+	"strconv"
 	"strings"
+	"sync"
+	"time"
 
 	"github.com/arelate/southern_light/vangogh_integration"
 	"github.com/arelate/theo/data"
@@ -21,18 +28,40 @@ func UpdateHandler(u *url.URL) error {
 	all := q.Has(vangogh_integration.UrlAllParameter)
 	verbose := q.Has(vangogh_integration.UrlVerboseParameter)
 	force := q.Has(vangogh_integration.UrlForceParameter)
+	auto := q.Has(data.UrlAutoParameter)
+	check := q.Has(data.UrlCheckParameter)
+	snapshot := q.Has(data.UrlSnapshotParameter)
+	allowDowngrade := q.Has(data.UrlAllowDowngradeParameter)
+
+	snapshotsLimit := defaultSnapshotsLimit
+	if q.Has(data.UrlSnapshotsLimitParameter) {
+		if sl, err := strconv.Atoi(q.Get(data.UrlSnapshotsLimitParameter)); err == nil && sl > 0 {
+			snapshotsLimit = sl
+		} else if err != nil {
+			return err
+		}
+	}
 
-	return Update(id, all, verbose, force)
+	return Update(id, all, auto, check, snapshot, snapshotsLimit, allowDowngrade, verbose, force)
 }
 
-func Update(id string, all, verbose, force bool) error {
+func Update(id string, all, auto, check, snapshot bool, snapshotsLimit int, allowDowngrade, verbose, force bool) error {
+
+	action := "updating"
+	if check {
+		action = "checking updates for"
+	}
 
 	var updateMsg string
 	switch all {
 	case false:
-		updateMsg = fmt.Sprintf("updating %s...", id)
+		updateMsg = fmt.Sprintf("%s %s...", action, id)
 	case true:
-		updateMsg = fmt.Sprintf("updating all products...")
+		updateMsg = fmt.Sprintf("%s all products...", action)
+	}
+
+	if auto {
+		updateMsg = fmt.Sprintf("%s auto-update products...", action)
 	}
 
 	ua := nod.NewProgress(updateMsg)
@@ -43,33 +72,129 @@ func Update(id string, all, verbose, force bool) error {
 		return err
 	}
 
-	updatedIdsInstallInfo, err := checkProductsUpdates(id, rdx, all, force)
+	puc, err := checkProductsUpdates(id, rdx, all, auto, allowDowngrade, force)
 	if err != nil {
 		return err
 	}
 
-	for updatedId, installedInfoSlice := range updatedIdsInstallInfo {
-		for _, installedInfo := range installedInfoSlice {
+	if check {
+		if len(puc.updates) == 0 && len(puc.failed) == 0 {
+			ua.EndWithResult("all products are up to date")
+			return nil
+		}
+
+		var summary map[string][]string
+		if summary, err = productUpdatesSummary(puc.updates, rdx); err != nil {
+			return err
+		}
+
+		for failedId, failedErr := range puc.failed {
+			summary["failed:"] = append(summary["failed:"], failedId+": "+failedErr.Error())
+		}
+
+		ua.EndWithSummary("available updates:", summary)
+
+		return puc.err()
+	}
+
+	updatedIds := make([]string, 0, len(puc.updates))
+
+	for updatedId, productUpdates := range puc.updates {
+		if err = updateProduct(updatedId, productUpdates, snapshot, snapshotsLimit, verbose, rdx); err != nil {
+			puc.failed[updatedId] = err
+			continue
+		}
+
+		updatedIds = append(updatedIds, updatedId)
+	}
+
+	ua.EndWithSummary("update results:", puc.summary(updatedIds))
+
+	return puc.err()
+}
+
+func updateProduct(id string, productUpdates []*productUpdate, snapshot bool, snapshotsLimit int, verbose bool, rdx redux.Writeable) error {
+
+	for _, pu := range productUpdates {
 
-			installedInfo.verbose = verbose
-			installedInfo.force = true // forcing installation to overwrite existing installation
-			installedInfo.Version = "" // reset Version, so that new one could be set during installation
+		start := time.Now()
 
-			if err = Install(updatedId, installedInfo); err != nil {
+		installedInfo := pu.installedInfo
+		installedInfo.verbose = verbose
+
+		if snapshot {
+			if err := createSnapshot(id, installedInfo, snapshotsLimit, rdx); err != nil {
+				return err
+			}
+		}
+
+		// GOG patches allow updating vangogh installations incrementally,
+		// full reinstall is only needed when there's no patch chain to the latest version
+		if installedInfo.Origin == data.VangoghOrigin {
+			if patched, err := vangoghPatchUpdate(id, installedInfo, rdx); err != nil {
 				return err
+			} else if patched {
+				if err = recordHistoryEvent(id, historyEventUpdate, installedInfo, start, rdx); err != nil {
+					return err
+				}
+				continue
 			}
 		}
+
+		installedInfo.force = true    // forcing installation to overwrite existing installation
+		installedInfo.updating = true // recording installation as an update in the product history
+		installedInfo.Version = ""    // reset Version, so that new one could be set during installation
+
+		if err := Install(id, installedInfo); err != nil {
+			return err
+		}
+	}
+
+	return rdx.CutKeys(data.UpdateAvailableProperty, id)
+}
+
+const maxUpdateCheckWorkers = 4
+
+// productsUpdatesCheck contains results of checking updates for multiple products:
+// available updates, products that are up to date and products that failed the check
+type productsUpdatesCheck struct {
+	updates  map[string][]*productUpdate
+	upToDate []string
+	failed   map[string]error
+}
+
+func (puc *productsUpdatesCheck) summary(updatedIds []string) map[string][]string {
+
+	summary := make(map[string][]string)
+
+	if len(updatedIds) > 0 {
+		summary["updated:"] = updatedIds
+	}
+
+	if len(puc.upToDate) > 0 {
+		summary["up to date:"] = puc.upToDate
+	}
+
+	for failedId, err := range puc.failed {
+		summary["failed:"] = append(summary["failed:"], failedId+": "+err.Error())
 	}
 
+	return summary
+}
+
+func (puc *productsUpdatesCheck) err() error {
+	if len(puc.failed) > 0 {
+		return fmt.Errorf("%d product(s) failed to update", len(puc.failed))
+	}
 	return nil
 }
 
-func checkProductsUpdates(id string, rdx redux.Writeable, all, force bool) (map[string][]*InstallInfo, error) {
+func checkProductsUpdates(id string, rdx redux.Writeable, all, auto, allowDowngrade, force bool) (*productsUpdatesCheck, error) {
 
 	cpua := nod.NewProgress("checking for products updates...")
 	defer cpua.Done()
 
-	if err := rdx.MustHave(data.InstallInfoProperty); err != nil {
+	if err := rdx.MustHave(data.InstallInfoProperty, data.UpdatePolicyProperty); err != nil {
 		return nil, err
 	}
 
@@ -78,47 +203,164 @@ func checkProductsUpdates(id string, rdx redux.Writeable, all, force bool) (map[
 		checkIds = append(checkIds, id)
 	}
 
-	if all {
+	if all || auto {
 		for installedId := range rdx.Keys(data.InstallInfoProperty) {
-			checkIds = append(checkIds, installedId)
+			if auto && getUpdatePolicy(installedId, rdx) != updatePolicyAuto {
+				continue
+			}
+			if !slices.Contains(checkIds, installedId) {
+				checkIds = append(checkIds, installedId)
+			}
 		}
 	}
 
 	cpua.TotalInt(len(checkIds))
 
-	updatedIdInstalledInfo := make(map[string][]*InstallInfo)
+	puc := &productsUpdatesCheck{
+		updates: make(map[string][]*productUpdate),
+		failed:  make(map[string]error),
+	}
+
+	var pinnedIds, ignoredIds, downgradeIds []string
+
+	// origin data shared by products is refreshed once, before checking products in parallel
+	if err := refreshSharedOriginData(checkIds, rdx); err != nil {
+		return nil, err
+	}
+
+	// origin metadata is fetched and stored one product at a time, as metadata stores
+	// can't be written concurrently, only version comparisons are done in parallel
+	policies := make(map[string]updatePolicy)
+	productsLatestData := make(map[string][]*installedInfoLatestData)
 
 	for _, checkId := range checkIds {
-		if uii, err := checkProductUpdates(checkId, rdx, force); err == nil && len(uii) > 0 {
-			updatedIdInstalledInfo[checkId] = uii
-		} else if err != nil {
-			return nil, err
+
+		policy := getUpdatePolicy(checkId, rdx)
+
+		// explicitly requested products can be forced to update regardless of policy
+		if policy == updatePolicyIgnore && !(force && checkId == id) {
+			ignoredIds = append(ignoredIds, checkId)
+			cpua.Increment()
+			continue
 		}
 
-		cpua.Increment()
+		policies[checkId] = policy
+
+		latestData, err := getProductLatestData(checkId, rdx)
+		if err != nil {
+			puc.failed[checkId] = err
+			cpua.Increment()
+			continue
+		}
+
+		productsLatestData[checkId] = latestData
 	}
 
-	updatedIds := make([]string, 0, len(updatedIdInstalledInfo))
-	for uid := range updatedIdInstalledInfo {
-		updatedIds = append(updatedIds, uid)
+	var mtx sync.Mutex
+	var wg sync.WaitGroup
+	workers := make(chan struct{}, maxUpdateCheckWorkers)
+
+	for checkId, latestData := range productsLatestData {
+
+		policy := policies[checkId]
+
+		workers <- struct{}{}
+
+		wg.Go(func() {
+			defer func() { <-workers }()
+
+			pus, err := checkProductUpdates(checkId, latestData, force)
+
+			mtx.Lock()
+			defer mtx.Unlock()
+
+			defer cpua.Increment()
+
+			if err != nil {
+				puc.failed[checkId] = err
+				return
+			}
+
+			// downgrades are only installed when explicitly allowed
+			if !allowDowngrade {
+				pus = slices.DeleteFunc(pus, func(pu *productUpdate) bool {
+					if pu.downgrade {
+						downgradeIds = append(downgradeIds, fmt.Sprintf("%s (%s)", checkId, pu))
+					}
+					return pu.downgrade
+				})
+			}
+
+			if err = recordProductUpdates(checkId, pus, rdx); err != nil {
+				puc.failed[checkId] = err
+				return
+			}
+
+			if policy == updatePolicyPin && !(force && checkId == id) {
+				for _, pu := range pus {
+					pinnedIds = append(pinnedIds, fmt.Sprintf("%s (%s)", checkId, pu))
+				}
+				if len(pus) == 0 {
+					pinnedIds = append(pinnedIds, checkId)
+				}
+			} else if len(pus) > 0 {
+				puc.updates[checkId] = pus
+			} else {
+				puc.upToDate = append(puc.upToDate, checkId)
+			}
+		})
 	}
 
-	if len(updatedIdInstalledInfo) > 0 {
+	wg.Wait()
+
+	updatedIds := slices.Sorted(maps.Keys(puc.updates))
+	slices.Sort(puc.upToDate)
+
+	if len(pinnedIds) > 0 || len(ignoredIds) > 0 || len(downgradeIds) > 0 {
+
+		summary := make(map[string][]string)
+
+		if len(updatedIds) > 0 {
+			summary["updates found:"] = updatedIds
+		}
+		if len(pinnedIds) > 0 {
+			summary["pinned, not updating:"] = pinnedIds
+		}
+		if len(ignoredIds) > 0 {
+			summary["ignored, not checked:"] = ignoredIds
+		}
+		if len(downgradeIds) > 0 {
+			summary["WARNING: latest versions are older than installed, use -allow-downgrade to install:"] = downgradeIds
+		}
+
+		cpua.EndWithSummary(fmt.Sprintf("found updates for %d product(s):", len(updatedIds)), summary)
+
+	} else if len(updatedIds) > 0 {
 		cpua.EndWithResult("found updates for: %s", strings.Join(updatedIds, ","))
+	} else if len(puc.failed) > 0 {
+		cpua.EndWithResult("failed to check updates for %d product(s)", len(puc.failed))
 	} else {
 		cpua.EndWithResult("all products are up to date")
 	}
 
-	return updatedIdInstalledInfo, nil
+	return puc, nil
 
 }
 
-func checkProductUpdates(id string, rdx redux.Writeable, force bool) ([]*InstallInfo, error) {
+// installedInfoLatestData is the latest origin data for an installed product
+type installedInfoLatestData struct {
+	installedInfo     InstallInfo
+	latestInstallInfo InstallInfo
+	originData        *data.OriginData
+}
 
-	cpua := nod.Begin(" checking product updates for %s...", id)
-	defer cpua.Done()
+// getProductLatestData fetches the latest origin data for every installation of a product
+func getProductLatestData(id string, rdx redux.Writeable) ([]*installedInfoLatestData, error) {
+
+	gplda := nod.Begin(" getting latest data for %s...", id)
+	defer gplda.Done()
 
-	updatedInstalledInfo := make([]*InstallInfo, 0)
+	latestData := make([]*installedInfoLatestData, 0)
 
 	if installedInfoLines, ok := rdx.GetAllValues(data.InstallInfoProperty, id); ok {
 
@@ -129,32 +371,101 @@ func checkProductUpdates(id string, rdx redux.Writeable, force bool) ([]*Install
 				return nil, err
 			}
 
-			if updated, err := originIsInstalledInfoUpdated(id, &installedInfo, rdx, force); updated && err == nil {
-				updatedInstalledInfo = append(updatedInstalledInfo, &installedInfo)
-			} else if err != nil {
+			// getting origin data updates install info with the latest origin values (e.g. version, size),
+			// so a copy is used to preserve installed values
+			ld := &installedInfoLatestData{
+				installedInfo:     installedInfo,
+				latestInstallInfo: installedInfo,
+			}
+
+			var err error
+			if ld.originData, err = originGetLatestData(id, &ld.latestInstallInfo, rdx); err != nil {
 				return nil, err
 			}
 
+			latestData = append(latestData, ld)
 		}
 
 	}
 
-	return updatedInstalledInfo, nil
+	return latestData, nil
+}
+
+func checkProductUpdates(id string, latestData []*installedInfoLatestData, force bool) ([]*productUpdate, error) {
+
+	cpua := nod.Begin(" checking product updates for %s...", id)
+	defer cpua.Done()
+
+	productUpdates := make([]*productUpdate, 0)
+
+	for _, ld := range latestData {
+		if pu, err := originIsInstalledInfoUpdated(id, ld, force); pu != nil && err == nil {
+			productUpdates = append(productUpdates, pu)
+		} else if err != nil {
+			return nil, err
+		}
+	}
+
+	return productUpdates, nil
 
 }
 
-func originIsInstalledInfoUpdated(id string, installedInfo *InstallInfo, rdx redux.Writeable, force bool) (bool, error) {
+// refreshSharedOriginData updates origin data used by all products of an origin,
+// e.g. EGS game assets, if any of the products is installed from that origin
+func refreshSharedOriginData(ids []string, rdx redux.Readable) error {
+
+	for _, id := range ids {
+		installedInfoLines, ok := rdx.GetAllValues(data.InstallInfoProperty, id)
+		if !ok {
+			continue
+		}
+
+		installedInfo, err := unmarshalInstalledInfoLines(installedInfoLines...)
+		if err != nil {
+			return err
+		}
+
+		if slices.ContainsFunc(installedInfo, func(ii InstallInfo) bool { return ii.Origin == data.EpicGamesOrigin }) {
+			_, err = egsGetGameAssets(true)
+			return err
+		}
+	}
+
+	return nil
+}
+
+// originGetLatestData gets the latest origin data for a product, same as originGetData with force,
+// except for shared origin data that is refreshed once with refreshSharedOriginData
+func originGetLatestData(id string, ii *InstallInfo, rdx redux.Writeable) (*data.OriginData, error) {
+
+	if ii.Origin != data.EpicGamesOrigin {
+		return originGetData(id, ii, rdx, true)
+	}
+
+	originData := new(data.OriginData)
+
+	if err := egsGetOriginData(id, ii, originData, rdx, false, true); err != nil {
+		return nil, err
+	}
+
+	if err := ii.reduceOriginData(id, originData); err != nil {
+		return nil, err
+	}
+
+	return originData, nil
+}
+
+func originIsInstalledInfoUpdated(id string, ld *installedInfoLatestData, force bool) (*productUpdate, error) {
+
+	installedInfo, originData := &ld.installedInfo, ld.originData
 
 	iiiua := nod.Begin(" checking %s (%s) %s-%s version...", id, installedInfo.Origin, installedInfo.OperatingSystem, installedInfo.LangCode)
 	defer iiiua.Done()
 
 	installedVersion := installedInfo.Version
-	var latestVersion string
-
-	originData, err := originGetData(id, installedInfo, rdx, true)
-	if err != nil {
-		return false, err
-	}
+	var latestVersion, changelog string
+	var patchBytes int64
+	var err error
 
 	switch installedInfo.Origin {
 	case data.VangoghOrigin:
@@ -162,38 +473,78 @@ func originIsInstalledInfoUpdated(id string, installedInfo *InstallInfo, rdx red
 		var downloadsList vangogh_integration.DownloadsList
 		downloadsList, err = vangogh_integration.FromDetails(originData.GogDetails)
 		if err != nil {
-			return false, err
+			return nil, err
 		}
 
 		latestVersion = vangoghDownloadsListVersion(downloadsList, installedInfo)
+		changelog = htmlToText(originData.GogDetails.GetChangelog())
+
+		if vangoghPatchesSupported(installedInfo.OperatingSystem) {
+			patches := vangoghGetPatches(downloadsList, installedInfo, originData.GogFilenames)
+			for _, patch := range vangoghPatchChain(patches, installedVersion, latestVersion) {
+				patchBytes += patch.estimatedBytes
+			}
+		}
 	case data.SteamOrigin:
 		latestVersion, err = steamAppInfoVersion(id, originData.AppInfoKv)
 		if err != nil {
-			return false, err
+			return nil, err
+		}
+
+		var timeUpdated time.Time
+		if timeUpdated, err = steamAppInfoTimeUpdated(id, originData.AppInfoKv); err == nil && !timeUpdated.IsZero() {
+			changelog = "build updated: " + timeUpdated.Local().Format(time.DateTime)
+		} else if err != nil {
+			return nil, err
 		}
 	case data.EpicGamesOrigin:
 		latestVersion = egsManifestVersion(originData.Manifest)
+		if latestVersion != "" {
+			changelog = "build version: " + latestVersion
+		}
 	default:
-		return false, installedInfo.Origin.ErrUnsupportedOrigin()
+		return nil, installedInfo.Origin.ErrUnsupportedOrigin()
 	}
 
 	if installedVersion == "" && !force {
 		iiiua.EndWithResult("cannot determine installed version")
-		return false, nil
+		return nil, nil
 	}
 
 	if latestVersion == "" && !force {
 		iiiua.EndWithResult("cannot determine latest version")
-		return false, nil
+		return nil, nil
 	}
 
 	if installedVersion == latestVersion {
 		iiiua.EndWithResult("already at the latest version: %s", installedVersion)
-		return false, nil
-	} else {
-		iiiua.EndWithResult("found update to install: %s -> %s", installedVersion, latestVersion)
-		return true, nil
+		return nil, nil
+	}
+
+	pu := &productUpdate{
+		installedInfo:    installedInfo,
+		installedVersion: installedVersion,
+		latestVersion:    latestVersion,
+		changelog:        changelog,
+		estimatedBytes:   ld.latestInstallInfo.EstimatedBytes,
+		patchBytes:       patchBytes,
+	}
+
+	// versions that can't be compared are considered updates, same as any version string difference
+	if comparison, ok := compareOriginVersions(installedInfo.Origin, installedVersion, latestVersion); ok {
+		switch {
+		case comparison == 0:
+			iiiua.EndWithResult("already at the latest version: %s (%s)", installedVersion, latestVersion)
+			return nil, nil
+		case comparison > 0:
+			pu.downgrade = true
+			iiiua.EndWithResult("WARNING: latest version is older than installed: %s -> %s", installedVersion, latestVersion)
+			return pu, nil
+		}
 	}
+
+	iiiua.EndWithResult("found update to install: %s -> %s", installedVersion, latestVersion)
+	return pu, nil
 }
 
 func vangoghDownloadsListVersion(downloadsList vangogh_integration.DownloadsList, ii *InstallInfo) string {
@@ -213,3 +564,106 @@ func vangoghDownloadsListVersion(downloadsList vangogh_integration.DownloadsList
 
 	return version
 }
+
+type productUpdate struct {
+	installedInfo    *InstallInfo
+	installedVersion string
+	latestVersion    string
+	changelog        string
+	estimatedBytes   int64
+	patchBytes       int64
+	downgrade        bool
+}
+
+func (pu *productUpdate) String() string {
+	puStr := fmt.Sprintf("%s: %s -> %s",
+		data.OsLangCode(pu.installedInfo.OperatingSystem, pu.installedInfo.LangCode),
+		pu.installedVersion,
+		pu.latestVersion)
+	if pu.downgrade {
+		puStr += " (downgrade)"
+	}
+	return puStr
+}
+
+func recordProductUpdates(id string, productUpdates []*productUpdate, rdx redux.Writeable) error {
+
+	if len(productUpdates) == 0 {
+		if rdx.HasKey(data.UpdateAvailableProperty, id) {
+			return rdx.CutKeys(data.UpdateAvailableProperty, id)
+		}
+		return nil
+	}
+
+	updateValues := make([]string, 0, len(productUpdates))
+	for _, pu := range productUpdates {
+		updateValues = append(updateValues, pu.String())
+	}
+
+	return rdx.ReplaceValues(data.UpdateAvailableProperty, id, updateValues...)
+}
+
+func productUpdatesSummary(idsProductUpdates map[string][]*productUpdate, rdx redux.Readable) (map[string][]string, error) {
+
+	summary := make(map[string][]string)
+
+	for id, productUpdates := range idsProductUpdates {
+		for _, pu := range productUpdates {
+
+			titleLine := fmt.Sprintf("%s: %s", pu.installedInfo.Origin, id)
+			if title, err := data.GetTitleProperty(id, rdx); err == nil && title != "" {
+				titleLine = fmt.Sprintf("%s (%s)", title, titleLine)
+			} else if err != nil {
+				return nil, err
+			}
+
+			summary[titleLine] = append(summary[titleLine], pu.String())
+
+			// patch updates only download the patches, otherwise the full installation is downloaded
+			if pu.patchBytes > 0 {
+				summary[titleLine] = append(summary[titleLine], "- patch download size: "+vangogh_integration.FormatBytes(pu.patchBytes))
+			} else if pu.estimatedBytes > 0 {
+				summary[titleLine] = append(summary[titleLine], "- full download size: "+vangogh_integration.FormatBytes(pu.estimatedBytes))
+			}
+
+			if pu.changelog != "" {
+				summary[titleLine] = append(summary[titleLine], "- changelog:")
+				for _, line := range strings.Split(pu.changelog, "\n") {
+					summary[titleLine] = append(summary[titleLine], "  "+line)
+				}
+			}
+		}
+	}
+
+	return summary, nil
+}
+
+var htmlTagsRegexp = regexp.MustCompile(`<[^>]*>`)
+
+const maxChangelogLines = 10
+
+// htmlToText converts GOG changelog HTML into plain text lines,
+// keeping only the most recent entries
+func htmlToText(htmlStr string) string {
+
+	for _, lineBreak := range []string{"<br>", "<br/>", "<br />", "</p>", "</li>", "</h1>", "</h2>", "</h3>", "</h4>"} {
+		htmlStr = strings.ReplaceAll(htmlStr, lineBreak, "\n")
+	}
+
+	text := html.UnescapeString(htmlTagsRegexp.ReplaceAllString(htmlStr, ""))
+
+	lines := make([]string, 0)
+	for _, line := range strings.Split(text, "\n") {
+		if line = strings.TrimSpace(line); line == "" {
+			continue
+		}
+		// only indicate truncation when there are more lines to show
+		if len(lines) == maxChangelogLines {
+			lines = append(lines, "...")
+			break
+		}
+		lines = append(lines, line)
+	}
+
+	return strings.Join(lines, "\n")
+}
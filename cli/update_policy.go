@@ -0,0 +1,97 @@
+package cli
+
+import (
+	"errors"
+	"net/url"
+
+	"github.com/arelate/southern_light/vangogh_integration"
+	"github.com/arelate/theo/data"
+	"github.com/boggydigital/nod"
+	"github.com/boggydigital/redux"
+)
+
+type updatePolicy string
+
+const (
+	updatePolicyNone   updatePolicy = ""
+	updatePolicyPin    updatePolicy = "pin"
+	updatePolicyIgnore updatePolicy = "ignore"
+	updatePolicyAuto   updatePolicy = "auto"
+)
+
+var allUpdatePolicies = []updatePolicy{
+	updatePolicyPin,
+	updatePolicyIgnore,
+	updatePolicyAuto,
+}
+
+func UpdatePolicyHandler(u *url.URL) error {
+
+	q := u.Query()
+
+	id := q.Get(vangogh_integration.UrlIdParameter)
+
+	policy := updatePolicyNone
+	for _, up := range allUpdatePolicies {
+		if q.Has(string(up)) {
+			if policy != updatePolicyNone {
+				return errors.New("update-policy requires exactly one policy: pin, ignore, auto")
+			}
+			policy = up
+		}
+	}
+
+	reset := q.Has(vangogh_integration.UrlResetParameter)
+
+	return UpdatePolicy(id, policy, reset)
+}
+
+func UpdatePolicy(id string, policy updatePolicy, reset bool) error {
+
+	upa := nod.Begin("setting update policy for %s...", id)
+	defer upa.Done()
+
+	rdx, err := redux.NewWriter(vangogh_integration.AbsReduxDir(), data.AllProperties()...)
+	if err != nil {
+		return err
+	}
+
+	if !rdx.HasKey(data.InstallInfoProperty, id) {
+		return ErrInstallInfoNotFound
+	}
+
+	if reset {
+		if err = rdx.CutKeys(data.UpdatePolicyProperty, id); err != nil {
+			return err
+		}
+		upa.EndWithResult("reset to default")
+		return nil
+	}
+
+	if policy == updatePolicyNone {
+		upa.EndWithResult("current policy: %s", getUpdatePolicy(id, rdx).String())
+		return nil
+	}
+
+	if err = rdx.ReplaceValues(data.UpdatePolicyProperty, id, string(policy)); err != nil {
+		return err
+	}
+
+	upa.EndWithResult("set to %s", policy)
+
+	return nil
+}
+
+func getUpdatePolicy(id string, rdx redux.Readable) updatePolicy {
+	if up, ok := rdx.GetLastVal(data.UpdatePolicyProperty, id); ok {
+		return updatePolicy(up)
+	}
+	return updatePolicyNone
+}
+
+func (up updatePolicy) String() string {
+	if up == updatePolicyNone {
+		return "default"
+	}
+	return string(up)
+}
@@ -20,6 +20,7 @@ const (
 	ValResMissingChecksum = "missing checksum"
 	ValResFileNotFound    = "file not found"
 	ValResValid           = "valid"
+	ValResValidCached     = "valid (cached)"
 )
 
 var allValidationResults = []ValidationResult{
@@ -28,6 +29,7 @@ var allValidationResults = []ValidationResult{
 	ValResMissingChecksum,
 	ValResFileNotFound,
 	ValResValid,
+	ValResValidCached,
 }
 
 var valResMessageTemplates = map[ValidationResult]string{
@@ -36,6 +38,7 @@ var valResMessageTemplates = map[ValidationResult]string{
 	ValResMissingChecksum: "%s files are missing checksums",
 	ValResFileNotFound:    "%s files were not found",
 	ValResValid:           "%s files are matching checksums",
+	ValResValidCached:     "%s files are unchanged since last validation",
 }
 
 func ValidateHandler(u *url.URL) error {
@@ -74,6 +77,13 @@ func ValidateHandler(u *url.URL) error {
 		manualUrlFilter = strings.Split(q.Get(vangogh_integration.UrlManualUrlFilterParameter), ",")
 	}
 
+	if q.Has(vangogh_integration.UrlInstalledParameter) || q.Has(data.UrlRepairParameter) {
+		if !q.Has(vangogh_integration.UrlSteamParameter) && !q.Has(vangogh_integration.UrlEpicGamesParameter) {
+			ii.Origin = data.UnknownOrigin
+		}
+		return ValidateInstalled(id, ii, q.Has(data.UrlRepairParameter))
+	}
+
 	return Validate(id, ii, manualUrlFilter...)
 }
 
@@ -98,7 +108,7 @@ func Validate(id string,
 	case data.VangoghOrigin:
 		return vangoghValidateData(id, ii, originData, rdx, manualUrlFilter...)
 	case data.SteamOrigin:
-		return steamUpdateApp(id, ii.OperatingSystem, rdx)
+		return steamValidateInstalled(id, ii, originData, rdx)
 	case data.EpicGamesOrigin:
 		return egsValidateChunks(id, ii, originData)
 	default:
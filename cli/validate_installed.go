@@ -0,0 +1,286 @@
+package cli
+
+import (
+	"errors"
+	"fmt"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"time"
+
+	"github.com/arelate/southern_light/vangogh_integration"
+	"github.com/arelate/theo/data"
+	"github.com/boggydigital/camino"
+	"github.com/boggydigital/nod"
+	"github.com/boggydigital/redux"
+)
+
+const (
+	installedFileModified = "modified"
+	installedFileMissing  = "missing"
+	installedFileExtra    = "extra"
+)
+
+func ValidateInstalled(id string, request *InstallInfo, repair bool) error {
+
+	via := nod.Begin("validating installed files for %s...", id)
+	defer via.Done()
+
+	rdx, err := redux.NewWriter(vangogh_integration.AbsReduxDir(), data.AllProperties()...)
+	if err != nil {
+		return err
+	}
+
+	installedInfo, err := matchInstalledInfo(id, request, rdx)
+	if err != nil {
+		return err
+	}
+
+	installedInfo.verbose = request.verbose
+	installedInfo.force = request.force
+
+	switch installedInfo.Origin {
+	case data.VangoghOrigin:
+		return vangoghValidateInstalled(id, installedInfo, rdx, repair)
+	case data.EpicGamesOrigin:
+		return egsValidateInstalled(id, installedInfo, rdx, repair)
+	default:
+		return errors.New("installed files validation is not supported for " + installedInfo.Origin.String())
+	}
+}
+
+func vangoghValidateInstalled(id string, ii *InstallInfo, rdx redux.Writeable, repair bool) error {
+
+	vvia := nod.NewProgress(" checking %s %s-%s files...", id, ii.OperatingSystem, ii.LangCode)
+	defer vvia.Done()
+
+	checksums, err := readInventoryChecksums(id, ii, rdx)
+	if err != nil {
+		return err
+	}
+
+	if len(checksums) == 0 {
+		return errors.New("no installed files checksums recorded for " + id + ", reinstall to record them")
+	}
+
+	absInstalledDir, err := originOsInstalledPath(id, ii, rdx)
+	if err != nil {
+		return err
+	}
+
+	vvia.TotalInt(len(checksums))
+
+	results := make(map[string][]string)
+
+	for _, relFile := range slices.Sorted(maps.Keys(checksums)) {
+
+		expected := checksums[relFile]
+
+		var actual *inventoryChecksum
+		if actual, err = newInventoryChecksum(filepath.Join(absInstalledDir, relFile)); os.IsNotExist(err) {
+			results[installedFileMissing] = append(results[installedFileMissing], relFile)
+		} else if err != nil {
+			return err
+		} else if actual.Size != expected.Size || actual.Sha256 != expected.Sha256 {
+			results[installedFileModified] = append(results[installedFileModified], relFile)
+		}
+
+		vvia.Increment()
+	}
+
+	installedFiles, err := relWalkDir(absInstalledDir)
+	if err != nil {
+		return err
+	}
+
+	for _, relFile := range installedFiles {
+		if _, ok := checksums[relFile]; !ok {
+			results[installedFileExtra] = append(results[installedFileExtra], relFile)
+		}
+	}
+
+	brokenFiles := append(results[installedFileModified], results[installedFileMissing]...)
+
+	summary := make(map[string][]string)
+	for _, category := range []string{installedFileModified, installedFileMissing, installedFileExtra} {
+		if len(results[category]) > 0 {
+			heading := fmt.Sprintf("%s (%d):", category, len(results[category]))
+			summary[heading] = results[category]
+		}
+	}
+
+	if len(summary) == 0 {
+		vvia.EndWithResult("all %d files are valid", len(checksums))
+	} else {
+		vvia.EndWithSummary(fmt.Sprintf("%d of %d files are not valid:", len(brokenFiles), len(checksums)), summary)
+	}
+
+	if repair && len(brokenFiles) > 0 {
+		return vangoghRepairInstalled(id, ii, brokenFiles, rdx)
+	}
+
+	return nil
+}
+
+func vangoghRepairInstalled(id string, ii *InstallInfo, brokenFiles []string, rdx redux.Writeable) error {
+
+	vria := nod.Begin("repairing %d file(s) for %s...", len(brokenFiles), id)
+	defer vria.Done()
+
+	start := time.Now()
+
+	// getting origin data updates install info with the latest origin values (e.g. version),
+	// so a copy is used to preserve installed values
+	repairInstallInfo := *ii
+
+	originData, err := originGetData(id, &repairInstallInfo, rdx, false)
+	if err != nil {
+		return err
+	}
+
+	downloadsList, err := vangogh_integration.FromDetails(originData.GogDetails)
+	if err != nil {
+		return err
+	}
+
+	// repair restores files from the currently downloadable installers,
+	// which would mix versions unless those match the installed version
+	if downloadableVersion := vangoghDownloadsListVersion(downloadsList, ii); downloadableVersion != ii.Version {
+		msg := fmt.Sprintf("installed version %s doesn't match downloadable version %s", ii.Version, downloadableVersion)
+		if !ii.force {
+			return errors.New(msg + ", update the product or use -force to repair with the downloadable version")
+		}
+		nod.Log("%s", msg)
+	}
+
+	downloadsList = downloadsList.
+		FilterOperatingSystems(ii.OperatingSystem).
+		FilterLangCodes(ii.LangCode).
+		FilterDownloadTypes(vangogh_integration.Installer, vangogh_integration.DLC).
+		FilterPatches(true)
+
+	var manualUrls []string
+	for _, dl := range downloadsList {
+		if dl.DownloadType == vangogh_integration.DLC &&
+			!slices.Contains(ii.DownloadableContent, vangoghDlcId(dl.ManualUrl)) {
+			continue
+		}
+		manualUrls = append(manualUrls, dl.ManualUrl)
+	}
+
+	repairInstallInfo.NoDlcs = false
+	repairInstallInfo.downloadTypes = []vangogh_integration.DownloadType{vangogh_integration.Installer, vangogh_integration.DLC}
+
+	if err = Download(id, &repairInstallInfo, originData, manualUrls...); err != nil {
+		return err
+	}
+
+	if !ii.NoValidation {
+		if err = Validate(id, &repairInstallInfo, manualUrls...); err != nil {
+			return err
+		}
+	}
+
+	repairDownloadsList := make(vangogh_integration.DownloadsList, 0, len(manualUrls))
+	for _, dl := range downloadsList {
+		if slices.Contains(manualUrls, dl.ManualUrl) {
+			repairDownloadsList = append(repairDownloadsList, dl)
+		}
+	}
+
+	unpackDir, err := vangoghGetUnpackDir(id, ii, rdx)
+	if err != nil {
+		return err
+	}
+
+	// unpacked files are always extracted again to avoid using stale data
+	repairInstallInfo.force = true
+
+	if err = vangoghUnpackInstallers(id, &repairInstallInfo, repairDownloadsList, originData.GogFilenames, rdx, unpackDir); err != nil {
+		return err
+	}
+
+	localFilenames := gogDownloadslocalFilenames(repairDownloadsList, originData.GogFilenames)
+
+	if err = vangoghPostUnpackActions(id, ii, localFilenames, unpackDir, rdx); err != nil {
+		return err
+	}
+
+	absInstalledDir, err := originOsInstalledPath(id, ii, rdx)
+	if err != nil {
+		return err
+	}
+
+	repairedFiles := make([]string, 0, len(brokenFiles))
+
+	for _, localFilename := range localFilenames {
+
+		if !isExecutable(localFilename, ii.OperatingSystem) {
+			continue
+		}
+
+		absPayloadDir := vangoghUnpackedPayloadDir(ii.OperatingSystem, unpackDir, localFilename)
+
+		for _, relFile := range brokenFiles {
+
+			if slices.Contains(repairedFiles, relFile) {
+				continue
+			}
+
+			absSrcPath := filepath.Join(absPayloadDir, relFile)
+			if _, err = os.Stat(absSrcPath); os.IsNotExist(err) {
+				continue
+			}
+
+			absDstPath := filepath.Join(absInstalledDir, relFile)
+			absDstDir, _ := filepath.Split(absDstPath)
+
+			if _, err = os.Stat(absDstDir); os.IsNotExist(err) {
+				if err = os.MkdirAll(absDstDir, camino.DefaultFileMode); err != nil {
+					return err
+				}
+			}
+
+			if err = os.Rename(absSrcPath, absDstPath); err != nil {
+				return err
+			}
+
+			repairedFiles = append(repairedFiles, relFile)
+		}
+	}
+
+	if err = os.RemoveAll(unpackDir); err != nil {
+		return err
+	}
+
+	if !ii.KeepDownloads {
+		if err = RemoveDownloads(id, &repairInstallInfo, rdx); err != nil {
+			return err
+		}
+	}
+
+	if len(repairedFiles) > 0 {
+		if err = refreshInventoryChecksums(id, ii, rdx, repairedFiles...); err != nil {
+			return err
+		}
+	}
+
+	summary := make(map[string][]string)
+	for _, relFile := range brokenFiles {
+		switch slices.Contains(repairedFiles, relFile) {
+		case true:
+			summary["repaired:"] = append(summary["repaired:"], relFile)
+		case false:
+			summary["not found in installers:"] = append(summary["not found in installers:"], relFile)
+		}
+	}
+
+	if err = recordHistoryEvent(id, historyEventRepair, ii, start, rdx); err != nil {
+		return err
+	}
+
+	vria.EndWithSummary(fmt.Sprintf("repaired %d of %d file(s):", len(repairedFiles), len(brokenFiles)), summary)
+
+	return nil
+}
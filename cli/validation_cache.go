@@ -0,0 +1,79 @@
+package cli
+
+import (
+	"bytes"
+	"encoding/json/v2"
+	"os"
+
+	"github.com/arelate/theo/data"
+	"github.com/boggydigital/kevlar"
+)
+
+const maxValidationWorkers = 4
+
+type validationCacheEntry struct {
+	Size     int64            `json:"size"`
+	ModTime  int64            `json:"mod-time"`
+	Checksum string           `json:"checksum"`
+	Result   ValidationResult `json:"result"`
+}
+
+func newValidationCacheEntry(stat os.FileInfo, checksum string, result ValidationResult) *validationCacheEntry {
+	return &validationCacheEntry{
+		Size:     stat.Size(),
+		ModTime:  stat.ModTime().UnixNano(),
+		Checksum: checksum,
+		Result:   result,
+	}
+}
+
+// matches returns true when the file hasn't changed since it was validated
+// against the same expected checksum
+func (vce *validationCacheEntry) matches(stat os.FileInfo, checksum string) bool {
+	return vce != nil &&
+		vce.Result == ValResValid &&
+		vce.Size == stat.Size() &&
+		vce.ModTime == stat.ModTime().UnixNano() &&
+		vce.Checksum == checksum
+}
+
+func readValidationCache(id string) (map[string]validationCacheEntry, error) {
+
+	kvValidationCache, err := kevlar.New(data.AbsValidationCacheDir(), kevlar.JsonExt)
+	if err != nil {
+		return nil, err
+	}
+
+	validationCache := make(map[string]validationCacheEntry)
+
+	if !kvValidationCache.Has(id) {
+		return validationCache, nil
+	}
+
+	rcValidationCache, err := kvValidationCache.Get(id)
+	if err != nil {
+		return nil, err
+	}
+	defer rcValidationCache.Close()
+
+	if err = json.UnmarshalRead(rcValidationCache, &validationCache); err != nil {
+		return nil, err
+	}
+
+	return validationCache, nil
+}
+
+func writeValidationCache(id string, validationCache map[string]validationCacheEntry) error {
+
+	kvValidationCache, err := kevlar.New(data.AbsValidationCacheDir(), kevlar.JsonExt)
+	if err != nil {
+		return err
+	}
+
+	buf := bytes.NewBuffer(nil)
+	if err = json.MarshalWrite(buf, validationCache); err != nil {
+		return err
+	}
+
+	return kvValidationCache.Set(id, buf)
+}
@@ -0,0 +1,255 @@
+package cli
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/arelate/southern_light/vangogh_integration"
+	"github.com/arelate/theo/data"
+	"github.com/boggydigital/camino"
+	"github.com/boggydigital/dolo"
+	"github.com/boggydigital/nod"
+	"github.com/boggydigital/redux"
+)
+
+func vangoghExtrasList(originData *data.OriginData) (vangogh_integration.DownloadsList, error) {
+
+	downloadsList, err := vangogh_integration.FromDetails(originData.GogDetails)
+	if err != nil {
+		return nil, err
+	}
+
+	extrasList := make(vangogh_integration.DownloadsList, 0)
+	for _, dl := range downloadsList {
+		if dl.DownloadType == vangogh_integration.Extra {
+			extrasList = append(extrasList, dl)
+		}
+	}
+
+	return extrasList, nil
+}
+
+func vangoghDownloadExtras(id string, ii *InstallInfo, originData *data.OriginData, rdx redux.Readable, manualUrlFilter ...string) error {
+
+	vdea := nod.Begin(" downloading extras for %s...", id)
+	defer vdea.Done()
+
+	extrasList, err := vangoghExtrasList(originData)
+	if err != nil {
+		return err
+	}
+
+	if len(manualUrlFilter) > 0 {
+		filteredList := make(vangogh_integration.DownloadsList, 0, len(extrasList))
+		for _, dl := range extrasList {
+			if slices.Contains(manualUrlFilter, dl.ManualUrl) {
+				filteredList = append(filteredList, dl)
+			}
+		}
+		extrasList = filteredList
+	}
+
+	if len(extrasList) == 0 {
+		vdea.EndWithResult("no extras available")
+		return nil
+	}
+
+	absExtrasDir, err := data.AbsExtrasDir(id, rdx)
+	if err != nil {
+		return err
+	}
+
+	var ok bool
+	if ok, err = hasFreeSpaceForBytes(camino.GetAbs(vangogh_integration.InstalledApps), extrasList.TotalBytesEstimate()); err != nil {
+		return err
+	} else if !ok && !ii.force {
+		return fmt.Errorf("not enough space for %s extras", id)
+	}
+
+	dc := dolo.DefaultClient
+
+	if token, sure := rdx.GetLastVal(data.VangoghSessionTokenProperty, data.VangoghSessionTokenProperty); sure && token != "" {
+		dc.SetAuthorizationBearer(token)
+	}
+
+	var errs []error
+
+	for _, dl := range extrasList {
+
+		var localFilename string
+		if localFilename = originData.GogFilenames[dl.ManualUrl]; localFilename == "" {
+			nod.Log("unresolved local filename for extra manual-url %s", dl.ManualUrl)
+			continue
+		}
+
+		fa := nod.NewProgress(" - %s...", localFilename)
+
+		manualUrlPath := path.Join(data.ApiGogManualUrlPath, id, dl.DownloadType.String(), dl.ManualUrl)
+
+		fileUrl, err := data.VangoghUrl(manualUrlPath, nil, rdx)
+		if err != nil {
+			fa.EndWithResult("%s", err.Error())
+			errs = append(errs, err)
+			continue
+		}
+
+		if err = dc.Download(fileUrl, ii.force, fa, absExtrasDir, localFilename); err != nil {
+			fa.EndWithResult("%s", err.Error())
+			errs = append(errs, err)
+			continue
+		}
+
+		fa.Done()
+	}
+
+	// remaining extras are still downloaded when some of them fail
+	return errors.Join(errs...)
+}
+
+func vangoghRemoveExtras(id string, originData *data.OriginData, rdx redux.Readable) error {
+
+	vrea := nod.Begin(" removing extras for %s...", id)
+	defer vrea.Done()
+
+	absExtrasDir, err := data.AbsExtrasDir(id, rdx)
+	if err != nil {
+		return err
+	}
+
+	if _, err = os.Stat(absExtrasDir); os.IsNotExist(err) {
+		vrea.EndWithResult("product extras dir not present")
+		return nil
+	}
+
+	extrasList, err := vangoghExtrasList(originData)
+	if err != nil {
+		return err
+	}
+
+	for _, dl := range extrasList {
+
+		var localFilename string
+		// if we don't do this - product extras dir itself will be removed
+		if localFilename = originData.GogFilenames[dl.ManualUrl]; localFilename == "" {
+			continue
+		}
+
+		absPath := filepath.Join(absExtrasDir, localFilename)
+
+		if _, err = os.Stat(absPath); os.IsNotExist(err) {
+			continue
+		}
+
+		fa := nod.Begin(" - %s...", localFilename)
+		if err = os.Remove(absPath); err != nil {
+			return err
+		}
+		fa.Done()
+	}
+
+	if entries, err := os.ReadDir(absExtrasDir); err == nil && len(entries) == 0 {
+		if err = os.Remove(absExtrasDir); err != nil {
+			return err
+		}
+	} else {
+		return err
+	}
+
+	return nil
+}
+
+func listExtras(id string, ii *InstallInfo) error {
+
+	lea := nod.Begin("listing extras for %s...", id)
+	defer lea.Done()
+
+	if ii.Origin == data.UnknownOrigin {
+		ii.Origin = data.VangoghOrigin
+	}
+
+	if ii.Origin != data.VangoghOrigin {
+		return errors.New("extras are not supported for " + ii.Origin.String())
+	}
+
+	rdx, err := redux.NewWriter(vangogh_integration.AbsReduxDir(), data.AllProperties()...)
+	if err != nil {
+		return err
+	}
+
+	originData, err := originGetData(id, ii, rdx, ii.force)
+	if err != nil {
+		return err
+	}
+
+	extrasList, err := vangoghExtrasList(originData)
+	if err != nil {
+		return err
+	}
+
+	if len(extrasList) == 0 {
+		lea.EndWithResult("found nothing")
+		return nil
+	}
+
+	absExtrasDir, err := data.AbsExtrasDir(id, rdx)
+	if err != nil {
+		return err
+	}
+
+	summary := make(map[string][]string)
+
+	for _, dl := range extrasList {
+
+		extraLine := dl.Name
+		if dl.Type != "" {
+			extraLine = fmt.Sprintf("%s (%s)", dl.Name, dl.Type)
+		}
+
+		infoLines := []string{"size: " + vangogh_integration.FormatBytes(dl.EstimatedBytes)}
+
+		if localFilename := originData.GogFilenames[dl.ManualUrl]; localFilename != "" {
+			infoLines = append(infoLines, "file: "+localFilename)
+			if _, err = os.Stat(filepath.Join(absExtrasDir, localFilename)); err == nil {
+				infoLines = append(infoLines, "downloaded")
+			}
+		}
+
+		summary[extraLine] = append(summary[extraLine], strings.Join(infoLines, "; "))
+	}
+
+	lea.EndWithSummary(fmt.Sprintf("found %d extra(s):", len(extrasList)), summary)
+
+	return nil
+}
+
+func revealExtras(id string) error {
+
+	rea := nod.Begin("revealing extras...")
+	defer rea.Done()
+
+	rdx, err := redux.NewReader(vangogh_integration.AbsReduxDir(),
+		vangogh_integration.GogTitleProperty,
+		vangogh_integration.SteamTitleProperty,
+		vangogh_integration.EgsTitleProperty)
+	if err != nil {
+		return err
+	}
+
+	absExtrasDir, err := data.AbsExtrasDir(id, rdx)
+	if err != nil {
+		return err
+	}
+
+	if _, err = os.Stat(absExtrasDir); err == nil {
+		return currentOsReveal(absExtrasDir)
+	} else if os.IsNotExist(err) {
+		return errors.New("no extras downloaded for " + id)
+	} else {
+		return err
+	}
+}
@@ -0,0 +1,473 @@
+package cli
+
+import (
+	"errors"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/arelate/southern_light/vangogh_integration"
+	"github.com/arelate/theo/data"
+	"github.com/boggydigital/camino"
+	"github.com/boggydigital/dolo"
+	"github.com/boggydigital/nod"
+	"github.com/boggydigital/redux"
+)
+
+const gogPatchStr = "patch"
+
+// GOG patches are named using from/to versions, optionally followed by a build number,
+// e.g. "Patch (1.5.1 to 1.5.2)" or "patch_title_4.03_(12345)_to_4.04_(12346).exe"
+var gogPatchVersionsRegexp = regexp.MustCompile(`(?i)([^\s_()]+)(?:[\s_]*\(\d+\))?[\s_]+to[\s_]+([^\s_()]+)`)
+
+var mojoSetupPatchArgs = []string{"--", "--i-agree-to-all-licenses", "--noreadme", "--nooptions", "--noprompt", "--destination"}
+
+type gogPatch struct {
+	from           string
+	to             string
+	manualUrl      string
+	localFilename  string
+	estimatedBytes int64
+}
+
+func vangoghPatchUpdate(id string, ii *InstallInfo, rdx redux.Writeable) (bool, error) {
+
+	vpua := nod.Begin("checking GOG patches for %s %s-%s...", id, ii.OperatingSystem, ii.LangCode)
+	defer vpua.Done()
+
+	if ii.Version == "" {
+		vpua.EndWithResult("installed version is unknown, full reinstall is required")
+		return false, nil
+	}
+
+	if !vangoghPatchesSupported(ii.OperatingSystem) {
+		switch ii.OperatingSystem {
+		case vangogh_integration.Windows:
+			vpua.EndWithResult("%s patches are applied with a WINE prefix, available on %s and %s hosts only, full reinstall is required",
+				ii.OperatingSystem, vangogh_integration.MacOS, vangogh_integration.Linux)
+		default:
+			vpua.EndWithResult("%s patches are not supported, full reinstall is required", ii.OperatingSystem)
+		}
+		return false, nil
+	}
+
+	originData, err := originGetData(id, ii, rdx, true)
+	if err != nil {
+		return false, err
+	}
+
+	downloadsList, err := vangogh_integration.FromDetails(originData.GogDetails)
+	if err != nil {
+		return false, err
+	}
+
+	latestVersion := vangoghDownloadsListVersion(downloadsList, ii)
+
+	patches := vangoghGetPatches(downloadsList, ii, originData.GogFilenames)
+
+	patchChain := vangoghPatchChain(patches, ii.Version, latestVersion)
+	if len(patchChain) == 0 {
+		vpua.EndWithResult("patch chain %s -> %s not found, full reinstall is required", ii.Version, latestVersion)
+		return false, nil
+	}
+
+	if err = vangoghDownloadPatches(id, ii, patchChain, rdx); err != nil {
+		return false, err
+	}
+
+	if !ii.NoValidation {
+		if err = vangoghValidatePatches(id, patchChain, rdx); err != nil {
+			return false, err
+		}
+	}
+
+	absInstalledDir, err := originOsInstalledPath(id, ii, rdx)
+	if err != nil {
+		return false, err
+	}
+
+	for _, patch := range patchChain {
+
+		var patchedFiles []string
+
+		switch ii.OperatingSystem {
+		case vangogh_integration.Windows:
+			patchedFiles, err = prefixApplyPatch(id, ii, patch, absInstalledDir, rdx)
+		case vangogh_integration.Linux:
+			patchedFiles, err = linuxApplyPatch(id, patch, absInstalledDir, ii.verbose)
+		default:
+			err = ii.OperatingSystem.ErrUnsupported()
+		}
+
+		if err != nil {
+			return false, err
+		}
+
+		if err = appendNewInventory(id, ii, rdx, patchedFiles...); err != nil {
+			return false, err
+		}
+
+		// patches can modify any of the recorded files, not just the new ones
+		if err = refreshInventoryChecksums(id, ii, rdx); err != nil {
+			return false, err
+		}
+
+		// each applied patch is recorded, so that an interrupted chain continues from the last applied patch
+		ii.Version = patch.to
+
+		if err = pinInstallInfo(id, ii, rdx); err != nil {
+			return false, err
+		}
+	}
+
+	if !ii.KeepDownloads {
+		if err = vangoghRemovePatches(id, patchChain); err != nil {
+			return false, err
+		}
+	}
+
+	vpua.EndWithResult("applied %d patch(es): %s -> %s", len(patchChain), patchChain[0].from, latestVersion)
+
+	return true, nil
+}
+
+func vangoghPatchesSupported(operatingSystem vangogh_integration.OperatingSystem) bool {
+	switch operatingSystem {
+	case vangogh_integration.Windows:
+		// Windows patches contain delta data that only their installer can apply, which requires a prefix
+		currentOs := vangogh_integration.CurrentOs()
+		return currentOs == vangogh_integration.MacOS || currentOs == vangogh_integration.Linux
+	case vangogh_integration.Linux:
+		return true
+	default:
+		return false
+	}
+}
+
+func vangoghGetPatches(downloadsList vangogh_integration.DownloadsList, ii *InstallInfo, gogFilenames map[string]string) []gogPatch {
+
+	dls := downloadsList.
+		FilterOperatingSystems(ii.OperatingSystem).
+		FilterLangCodes(ii.LangCode).
+		FilterDownloadTypes(vangogh_integration.Installer)
+
+	patches := make([]gogPatch, 0)
+
+	for _, dl := range dls {
+
+		if !strings.Contains(path.Base(dl.ManualUrl), gogPatchStr) {
+			continue
+		}
+
+		localFilename := gogFilenames[dl.ManualUrl]
+		if localFilename == "" {
+			continue
+		}
+
+		from, to := parsePatchVersions(dl.Name)
+		if from == "" || to == "" {
+			from, to = parsePatchVersions(strings.TrimSuffix(localFilename, filepath.Ext(localFilename)))
+		}
+
+		if from == "" || to == "" {
+			continue
+		}
+
+		patches = append(patches, gogPatch{
+			from:           from,
+			to:             to,
+			manualUrl:      dl.ManualUrl,
+			localFilename:  localFilename,
+			estimatedBytes: dl.EstimatedBytes,
+		})
+	}
+
+	return patches
+}
+
+func parsePatchVersions(s string) (string, string) {
+	if match := gogPatchVersionsRegexp.FindStringSubmatch(s); len(match) == 3 {
+		return normalizePatchVersion(match[1]), normalizePatchVersion(match[2])
+	}
+	return "", ""
+}
+
+func normalizePatchVersion(version string) string {
+	if fields := strings.Fields(version); len(fields) > 0 {
+		return strings.ToLower(fields[0])
+	}
+	return ""
+}
+
+func vangoghPatchChain(patches []gogPatch, fromVersion, toVersion string) []gogPatch {
+
+	from, to := normalizePatchVersion(fromVersion), normalizePatchVersion(toVersion)
+	if from == "" || to == "" || from == to {
+		return nil
+	}
+
+	// breadth-first search produces the shortest chain of patches
+	prevPatch := map[string]int{from: -1}
+	queue := []string{from}
+
+	for len(queue) > 0 {
+
+		version := queue[0]
+		queue = queue[1:]
+
+		if version == to {
+			break
+		}
+
+		for jj, patch := range patches {
+			if patch.from != version {
+				continue
+			}
+			if _, ok := prevPatch[patch.to]; ok {
+				continue
+			}
+			prevPatch[patch.to] = jj
+			queue = append(queue, patch.to)
+		}
+	}
+
+	if _, ok := prevPatch[to]; !ok {
+		return nil
+	}
+
+	chain := make([]gogPatch, 0)
+	for version := to; version != from; {
+		patch := patches[prevPatch[version]]
+		chain = append(chain, patch)
+		version = patch.from
+	}
+
+	slices.Reverse(chain)
+
+	return chain
+}
+
+func vangoghDownloadPatches(id string, ii *InstallInfo, patches []gogPatch, rdx redux.Readable) error {
+
+	vdpa := nod.Begin(" downloading patches for %s...", id)
+	defer vdpa.Done()
+
+	if err := rdx.MustHave(data.VangoghProperties()...); err != nil {
+		return err
+	}
+
+	downloadsDir := camino.GetAbs(vangogh_integration.Downloads)
+
+	dc := dolo.DefaultClient
+
+	if token, ok := rdx.GetLastVal(data.VangoghSessionTokenProperty, data.VangoghSessionTokenProperty); ok && token != "" {
+		dc.SetAuthorizationBearer(token)
+	}
+
+	for _, patch := range patches {
+
+		fa := nod.NewProgress(" - %s...", patch.localFilename)
+
+		manualUrlPath := path.Join(data.ApiGogManualUrlPath, id, vangogh_integration.Installer.String(), patch.manualUrl)
+
+		fileUrl, err := data.VangoghUrl(manualUrlPath, nil, rdx)
+		if err != nil {
+			return err
+		}
+
+		if err = dc.Download(fileUrl, ii.force, fa, downloadsDir, id, patch.localFilename); err != nil {
+			return err
+		}
+
+		fa.Done()
+	}
+
+	return nil
+}
+
+func vangoghValidatePatches(id string, patches []gogPatch, rdx redux.Writeable) error {
+
+	vpa := nod.Begin(" validating patches for %s...", id)
+	defer vpa.Done()
+
+	manualUrlChecksums, err := vangoghGetGogChecksums(id, rdx, true)
+	if err != nil {
+		return err
+	}
+
+	downloadsDir := camino.GetAbs(vangogh_integration.Downloads)
+
+	for _, patch := range patches {
+
+		absPatchPath := filepath.Join(downloadsDir, id, patch.localFilename)
+
+		var vr ValidationResult
+		if vr, _, err = vangoghValidateLink(absPatchPath, manualUrlChecksums[patch.manualUrl], nil); err != nil {
+			return err
+		}
+
+		switch vr {
+		case ValResValid:
+			fallthrough
+		case ValResMissingChecksum:
+			// do nothing
+		default:
+			return errors.New("patch validation failed for " + patch.localFilename)
+		}
+	}
+
+	return nil
+}
+
+// prefixApplyPatch runs the patch installer in the product prefix against the installed directory,
+// since Windows patches contain delta data that can't be applied by copying unpacked files
+func prefixApplyPatch(id string, ii *InstallInfo, patch gogPatch, absInstalledDir string, rdx redux.Readable) ([]string, error) {
+
+	papa := nod.Begin(" applying patch %s -> %s...", patch.from, patch.to)
+	defer papa.Done()
+
+	downloadsDir := camino.GetAbs(vangogh_integration.Downloads)
+	absPatchPath := filepath.Join(downloadsDir, id, patch.localFilename)
+
+	absPrefixDir, err := data.AbsPrefixDir(id, ii.Origin, rdx)
+	if err != nil {
+		return nil, err
+	}
+
+	filesBefore, err := relWalkDir(absInstalledDir)
+	if err != nil {
+		return nil, err
+	}
+
+	et := &execTask{
+		title:   patch.localFilename,
+		exe:     absPatchPath,
+		workDir: downloadsDir,
+		prefix:  absPrefixDir,
+		args: []string{
+			innoSetupVerySilentArg,
+			innoSetupNoRestartArg,
+			innoSetupCloseApplicationsArg,
+			strings.Replace(innoSetupDirArgTemplate, "{dir}", nixToWinePath(absInstalledDir), 1)},
+		env:     ii.Env,
+		verbose: ii.verbose,
+	}
+
+	if et.absLogPath, err = newRunLog(id, runLogKindInstall, rdx); err != nil {
+		return nil, err
+	}
+
+	switch vangogh_integration.CurrentOs() {
+	case vangogh_integration.MacOS:
+		err = macOsWineExecTask(id, et)
+	case vangogh_integration.Linux:
+		err = linuxProtonExecTask(id, et)
+	default:
+		err = vangogh_integration.CurrentOs().ErrUnsupported()
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	filesAfter, err := relWalkDir(absInstalledDir)
+	if err != nil {
+		return nil, err
+	}
+
+	return addedFiles(filesBefore, filesAfter), nil
+}
+
+func linuxApplyPatch(id string, patch gogPatch, absInstalledDir string, verbose bool) ([]string, error) {
+
+	lapa := nod.Begin(" applying patch %s -> %s...", patch.from, patch.to)
+	defer lapa.Done()
+
+	absPatchPath := filepath.Join(camino.GetAbs(vangogh_integration.Downloads), id, patch.localFilename)
+
+	if err := chmodExecutable(absPatchPath); err != nil {
+		return nil, err
+	}
+
+	filesBefore, err := relWalkDir(absInstalledDir)
+	if err != nil {
+		return nil, err
+	}
+
+	args := append(slices.Clone(mojoSetupPatchArgs), absInstalledDir)
+
+	cmd := exec.Command(absPatchPath, args...)
+
+	if verbose {
+		cmd.Stdout = os.Stdout
+		cmd.Stderr = os.Stderr
+	}
+
+	if err = cmd.Run(); err != nil {
+		return nil, err
+	}
+
+	filesAfter, err := relWalkDir(absInstalledDir)
+	if err != nil {
+		return nil, err
+	}
+
+	return addedFiles(filesBefore, filesAfter), nil
+}
+
+func addedFiles(filesBefore, filesAfter []string) []string {
+
+	newFiles := make([]string, 0)
+	for _, file := range filesAfter {
+		if !slices.Contains(filesBefore, file) {
+			newFiles = append(newFiles, file)
+		}
+	}
+
+	return newFiles
+}
+
+func appendNewInventory(id string, ii *InstallInfo, rdx redux.Readable, files ...string) error {
+
+	inventory, err := readInventory(id, ii, rdx)
+	if err != nil {
+		return err
+	}
+
+	newFiles := make([]string, 0, len(files))
+	for _, file := range files {
+		if !slices.Contains(inventory, file) {
+			newFiles = append(newFiles, file)
+		}
+	}
+
+	if len(newFiles) == 0 {
+		return nil
+	}
+
+	return appendInventory(id, ii.LangCode, ii.OperatingSystem, rdx, newFiles...)
+}
+
+func vangoghRemovePatches(id string, patches []gogPatch) error {
+
+	vrpa := nod.Begin(" removing patches for %s...", id)
+	defer vrpa.Done()
+
+	downloadsDir := camino.GetAbs(vangogh_integration.Downloads)
+
+	for _, patch := range patches {
+		absPatchPath := filepath.Join(downloadsDir, id, patch.localFilename)
+		if _, err := os.Stat(absPatchPath); os.IsNotExist(err) {
+			continue
+		}
+		if err := os.Remove(absPatchPath); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -12,6 +12,7 @@ import (
 	"path"
 	"path/filepath"
 	"slices"
+	"sync"
 	"time"
 
 	"github.com/arelate/southern_light/gog_integration"
@@ -55,10 +56,20 @@ func vangoghUninstallProduct(id string, ii *InstallInfo, rdx redux.Writeable) er
 	oupa := nod.Begin(" uninstalling %s %s-%s...", id, ii.OperatingSystem, ii.LangCode)
 	defer oupa.Done()
 
+	for _, dlcId := range ii.DownloadableContent {
+		if err := vangoghUninstallDlc(id, dlcId, ii, rdx); err != nil {
+			return err
+		}
+	}
+
 	if err := removeInventoriedFiles(id, ii, rdx); err != nil {
 		return err
 	}
 
+	if err := removeInventoryChecksumsFile(id, ii, rdx); err != nil {
+		return err
+	}
+
 	return removeInventoryFile(id, ii, rdx)
 }
 
@@ -248,7 +259,7 @@ func vangoghUpdateSessionToken(password string, rdx redux.Writeable) error {
 	return nil
 }
 
-func vangoghUnpackPlace(id string, ii *InstallInfo, dt vangogh_integration.DownloadType, originData *data.OriginData, rdx redux.Writeable) error {
+func vangoghUnpackPlace(id string, ii *InstallInfo, dt vangogh_integration.DownloadType, originData *data.OriginData, rdx redux.Writeable, manualUrlFilter ...string) error {
 
 	ipa := nod.Begin("unpacking and placing %s %s-%s...", id, ii.OperatingSystem, ii.LangCode)
 	defer ipa.Done()
@@ -264,17 +275,28 @@ func vangoghUnpackPlace(id string, ii *InstallInfo, dt vangogh_integration.Downl
 		FilterDownloadTypes(dt).
 		FilterPatches(true)
 
-	if dt == vangogh_integration.DLC {
-		dlcNames := make(map[string]any)
-
+	if len(manualUrlFilter) > 0 {
+		filteredList := make(vangogh_integration.DownloadsList, 0, len(downloadsList))
 		for _, dl := range downloadsList {
-			if dl.DownloadType == vangogh_integration.DLC {
-				dlcNames[dl.Name] = nil
+			if slices.Contains(manualUrlFilter, dl.ManualUrl) {
+				filteredList = append(filteredList, dl)
 			}
 		}
+		downloadsList = filteredList
+	}
 
-		if len(dlcNames) > 0 {
-			ii.DownloadableContent = slices.Collect(maps.Keys(dlcNames))
+	dlcIds := vangoghDownloadsListDlcIds(downloadsList)
+
+	if dt == vangogh_integration.DLC && len(dlcIds) > 0 {
+		switch len(manualUrlFilter) {
+		case 0:
+			ii.DownloadableContent = dlcIds
+		default:
+			for _, dlcId := range dlcIds {
+				if !slices.Contains(ii.DownloadableContent, dlcId) {
+					ii.DownloadableContent = append(ii.DownloadableContent, dlcId)
+				}
+			}
 		}
 	}
 
@@ -290,7 +312,7 @@ func vangoghUnpackPlace(id string, ii *InstallInfo, dt vangogh_integration.Downl
 	// 2. unpack installers (e.g. pkgutil on macOS, extract .sh on Linux; innoextract/run setup on Windows)
 	// 3. perform post-unpack actions (e.g. reduce bundleName on macOS)
 	// 4. uninstall if installed directory exists and forcing install (will be used for updates)
-	// 5. create inventory of unpacked files
+	// 5. create inventory of unpacked files, record their sizes and checksums
 	// 6. place (move unpacked to install folder)
 	// 7. perform post-install actions (e.g. run post-install script and remove xattrs on macOS)
 	// 8. cleanup unpack directory
@@ -323,19 +345,47 @@ func vangoghUnpackPlace(id string, ii *InstallInfo, dt vangogh_integration.Downl
 		return err
 	}
 
-	if _, err = os.Stat(absInstalledDir); err == nil && ii.force {
+	// DLCs are placed into existing main product installation, so it can't be removed here
+	if _, err = os.Stat(absInstalledDir); err == nil && ii.force && dt == vangogh_integration.Installer {
 		if err = vangoghUninstallProduct(id, ii, rdx); err != nil {
 			return err
 		}
 	}
 
 	// 5
-	unpackedInventory, err := vangoghGetInventory(ii, downloadsList, originData.GogFilenames, unpackDir)
-	if err != nil {
-		return err
+	switch dt {
+	case vangogh_integration.DLC:
+		// each DLC has its own inventory, so that it can be uninstalled separately
+		for _, dlcId := range dlcIds {
+
+			var dlcInventory []string
+			dlcInventory, err = vangoghGetInventory(ii, vangoghDownloadsListDlc(downloadsList, dlcId), originData.GogFilenames, unpackDir)
+			if err != nil {
+				return err
+			}
+
+			if err = removeDlcInventoryFile(id, dlcId, ii, rdx); err != nil {
+				return err
+			}
+
+			if err = appendDlcInventory(id, dlcId, ii, rdx, dlcInventory...); err != nil {
+				return err
+			}
+		}
+	default:
+		var unpackedInventory []string
+		unpackedInventory, err = vangoghGetInventory(ii, downloadsList, originData.GogFilenames, unpackDir)
+		if err != nil {
+			return err
+		}
+
+		if err = appendInventory(id, ii.LangCode, ii.OperatingSystem, rdx, unpackedInventory...); err != nil {
+			return err
+		}
 	}
 
-	if err = appendInventory(id, ii.LangCode, ii.OperatingSystem, rdx, unpackedInventory...); err != nil {
+	// record size and checksum of every unpacked file to allow validating installed files later
+	if err = vangoghRecordPayloadChecksums(id, ii, localFilenames, unpackDir, rdx); err != nil {
 		return err
 	}
 
@@ -519,6 +569,24 @@ func vangoghDownloadData(id string, ii *InstallInfo, originData *data.OriginData
 		return err
 	}
 
+	if ii.includesDownloadType(vangogh_integration.Extra) {
+		if err := vangoghDownloadExtras(id, ii, originData, rdx, manualUrlFilter...); err != nil {
+			return err
+		}
+	}
+
+	downloadTypes := make([]vangogh_integration.DownloadType, 0, 2)
+	if ii.includesDownloadType(vangogh_integration.Installer) {
+		downloadTypes = append(downloadTypes, vangogh_integration.Installer)
+	}
+	if !ii.NoDlcs && ii.includesDownloadType(vangogh_integration.DLC) {
+		downloadTypes = append(downloadTypes, vangogh_integration.DLC)
+	}
+
+	if len(downloadTypes) == 0 {
+		return nil
+	}
+
 	downloadsDir := camino.GetAbs(vangogh_integration.Downloads)
 
 	if err := originHasFreeSpace(id, downloadsDir, ii, originData, manualUrlFilter...); err != nil {
@@ -531,13 +599,6 @@ func vangoghDownloadData(id string, ii *InstallInfo, originData *data.OriginData
 		dc.SetAuthorizationBearer(token)
 	}
 
-	downloadTypes := []vangogh_integration.DownloadType{vangogh_integration.Installer}
-	switch ii.NoDlcs {
-	case false:
-		downloadTypes = append(downloadTypes, vangogh_integration.DLC)
-	default: // no nothing
-	}
-
 	downloadsList, err := vangogh_integration.FromDetails(originData.GogDetails)
 	if err != nil {
 		return err
@@ -599,11 +660,18 @@ func vangoghRemoveProductDownloadLinks(id string,
 		return nil
 	}
 
-	downloadTypes := []vangogh_integration.DownloadType{vangogh_integration.Installer}
-	if !ii.NoDlcs {
+	downloadTypes := make([]vangogh_integration.DownloadType, 0, 2)
+	if ii.includesDownloadType(vangogh_integration.Installer) {
+		downloadTypes = append(downloadTypes, vangogh_integration.Installer)
+	}
+	if !ii.NoDlcs && ii.includesDownloadType(vangogh_integration.DLC) {
 		downloadTypes = append(downloadTypes, vangogh_integration.DLC)
 	}
 
+	if len(downloadTypes) == 0 {
+		return nil
+	}
+
 	downloadsList, err := vangogh_integration.FromDetails(originData.GogDetails)
 	if err != nil {
 		return err
@@ -772,12 +840,27 @@ func vangoghValidateLinks(id string,
 		return nil, errors.New("no links are matching operating params")
 	}
 
+	validationCache, err := readValidationCache(id)
+	if err != nil {
+		return nil, err
+	}
+
 	vla.TotalInt(len(downloadsList))
 
 	results := make([]ValidationResult, 0, len(downloadsList))
 
 	var mismatchedManualUrls []string
 
+	type linkValidation struct {
+		manualUrl       string
+		absDownloadPath string
+		cachedEntry     *validationCacheEntry
+	}
+
+	// cache entries are looked up before validation starts, so that the cache
+	// is only read while the workers are running
+	linkValidations := make([]linkValidation, 0, len(downloadsList))
+
 	for _, dl := range downloadsList {
 		if len(manualUrlFilter) > 0 && !slices.Contains(manualUrlFilter, dl.ManualUrl) {
 			continue
@@ -788,17 +871,66 @@ func vangoghValidateLinks(id string,
 			continue
 		}
 
-		var vr ValidationResult
-		vr, err = vangoghValidateLink(id, localFilename, manualUrlChecksums[dl.ManualUrl], downloadsDir)
-		if err != nil {
-			vla.Error(err)
+		lv := linkValidation{
+			manualUrl:       dl.ManualUrl,
+			absDownloadPath: filepath.Join(downloadsDir, id, localFilename),
 		}
 
-		if vr == ValResMismatch {
-			mismatchedManualUrls = append(mismatchedManualUrls, dl.ManualUrl)
+		if entry, ok := validationCache[lv.absDownloadPath]; ok && !ii.force {
+			lv.cachedEntry = &entry
 		}
 
-		results = append(results, vr)
+		linkValidations = append(linkValidations, lv)
+	}
+
+	var mtx sync.Mutex
+	var wg sync.WaitGroup
+	workers := make(chan struct{}, maxValidationWorkers)
+
+	updatedCache := make(map[string]validationCacheEntry)
+	var invalidatedPaths []string
+
+	for _, lv := range linkValidations {
+
+		workers <- struct{}{}
+
+		wg.Go(func() {
+			defer func() { <-workers }()
+
+			vr, entry, err := vangoghValidateLink(lv.absDownloadPath, manualUrlChecksums[lv.manualUrl], lv.cachedEntry)
+
+			mtx.Lock()
+			defer mtx.Unlock()
+
+			if err != nil {
+				vla.Error(err)
+			}
+
+			if vr == ValResMismatch {
+				mismatchedManualUrls = append(mismatchedManualUrls, lv.manualUrl)
+			}
+
+			if entry != nil {
+				updatedCache[lv.absDownloadPath] = *entry
+			} else {
+				invalidatedPaths = append(invalidatedPaths, lv.absDownloadPath)
+			}
+
+			results = append(results, vr)
+			vla.Increment()
+		})
+	}
+
+	wg.Wait()
+
+	for _, absDownloadPath := range invalidatedPaths {
+		delete(validationCache, absDownloadPath)
+	}
+
+	maps.Copy(validationCache, updatedCache)
+
+	if err = writeValidationCache(id, validationCache); err != nil {
+		return nil, err
 	}
 
 	vla.EndWithResult(summarizeValidationResults(results))
@@ -806,45 +938,53 @@ func vangoghValidateLinks(id string,
 	return mismatchedManualUrls, nil
 }
 
-func vangoghValidateLink(id string, localFilename string, manualUrlMd5 string, downloadsDir string) (ValidationResult, error) {
+// vangoghValidateLink computes MD5 of a downloaded file, unless it hasn't changed
+// since it was validated, and returns a cache entry for valid files
+func vangoghValidateLink(absDownloadPath string, manualUrlMd5 string, cachedEntry *validationCacheEntry) (ValidationResult, *validationCacheEntry, error) {
+
+	_, localFilename := filepath.Split(absDownloadPath)
 
 	dla := nod.NewProgress(" - %s...", localFilename)
 	defer dla.Done()
 
-	absDownloadPath := filepath.Join(downloadsDir, id, localFilename)
-
 	var stat os.FileInfo
 	var err error
 
 	if stat, err = os.Stat(absDownloadPath); os.IsNotExist(err) {
 		dla.EndWithResult(ValResFileNotFound)
-		return ValResFileNotFound, nil
+		return ValResFileNotFound, nil, nil
 	}
 
 	if manualUrlMd5 == "" {
 		dla.EndWithResult(ValResMissingChecksum)
-		return ValResMissingChecksum, nil
+		return ValResMissingChecksum, nil, nil
+	}
+
+	if cachedEntry.matches(stat, manualUrlMd5) {
+		dla.EndWithResult(ValResValidCached)
+		return ValResValidCached, cachedEntry, nil
 	}
 
 	dla.Total(uint64(stat.Size()))
 
 	localFile, err := os.Open(absDownloadPath)
 	if err != nil {
-		return ValResError, err
+		return ValResError, nil, err
 	}
+	defer localFile.Close()
 
 	h := md5.New()
 	if err = dolo.CopyWithProgress(h, localFile, dla); err != nil {
-		return ValResError, err
+		return ValResError, nil, err
 	}
 
 	computedMd5 := fmt.Sprintf("%x", h.Sum(nil))
 	if manualUrlMd5 == computedMd5 {
 		dla.EndWithResult(ValResValid)
-		return ValResValid, nil
+		return ValResValid, newValidationCacheEntry(stat, manualUrlMd5, ValResValid), nil
 	} else {
 		dla.EndWithResult(ValResMismatch)
-		return ValResMismatch, nil
+		return ValResMismatch, nil, nil
 	}
 }
 
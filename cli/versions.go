@@ -0,0 +1,137 @@
+package cli
+
+import (
+	"cmp"
+	"regexp"
+	"slices"
+	"strconv"
+	"time"
+
+	"github.com/arelate/theo/data"
+)
+
+var (
+	versionNumbersRegexp = regexp.MustCompile(`\d+`)
+	// GOG versions often include build dates, e.g. "1.2.3 (2023-05-12)" or "2023.05.12"
+	gogVersionDateRegexp = regexp.MustCompile(`(\d{4})[-._/](\d{2})[-._/](\d{2})`)
+	// EGS build versions often include a changelist number, e.g. "++Game+Release-1.2-CL-12345678-Windows"
+	egsChangelistRegexp = regexp.MustCompile(`(?i)CL-?(\d+)`)
+)
+
+// compareOriginVersions compares installed and latest versions using origin specific semantics.
+// The result is negative if installed version is older than the latest, positive if newer
+// and zero if versions are equivalent. Second value is false when versions can't be compared
+func compareOriginVersions(origin data.Origin, installedVersion, latestVersion string) (int, bool) {
+
+	if installedVersion == "" || latestVersion == "" {
+		return 0, false
+	}
+
+	if installedVersion == latestVersion {
+		return 0, true
+	}
+
+	switch origin {
+	case data.VangoghOrigin:
+		return compareGogVersions(installedVersion, latestVersion)
+	case data.SteamOrigin:
+		return compareSteamBuildIds(installedVersion, latestVersion)
+	case data.EpicGamesOrigin:
+		return compareEgsBuildVersions(installedVersion, latestVersion)
+	default:
+		return 0, false
+	}
+}
+
+func compareGogVersions(installedVersion, latestVersion string) (int, bool) {
+
+	// build dates are more reliable than version numbers that are not always consistent
+	installedDate, installedOk := gogVersionDate(installedVersion)
+	latestDate, latestOk := gogVersionDate(latestVersion)
+
+	if installedOk && latestOk && !installedDate.Equal(latestDate) {
+		return installedDate.Compare(latestDate), true
+	}
+
+	return compareVersionNumbers(installedVersion, latestVersion)
+}
+
+func gogVersionDate(version string) (time.Time, bool) {
+
+	matches := gogVersionDateRegexp.FindStringSubmatch(version)
+	if len(matches) < 4 {
+		return time.Time{}, false
+	}
+
+	date, err := time.Parse(time.DateOnly, matches[1]+"-"+matches[2]+"-"+matches[3])
+	if err != nil {
+		return time.Time{}, false
+	}
+
+	return date, true
+}
+
+func compareSteamBuildIds(installedBuildId, latestBuildId string) (int, bool) {
+
+	installed, err := strconv.ParseInt(installedBuildId, 10, 64)
+	if err != nil {
+		return 0, false
+	}
+
+	latest, err := strconv.ParseInt(latestBuildId, 10, 64)
+	if err != nil {
+		return 0, false
+	}
+
+	return cmp.Compare(installed, latest), true
+}
+
+func compareEgsBuildVersions(installedBuildVersion, latestBuildVersion string) (int, bool) {
+
+	installedMatches := egsChangelistRegexp.FindStringSubmatch(installedBuildVersion)
+	latestMatches := egsChangelistRegexp.FindStringSubmatch(latestBuildVersion)
+
+	if len(installedMatches) > 1 && len(latestMatches) > 1 {
+		return compareSteamBuildIds(installedMatches[1], latestMatches[1])
+	}
+
+	return compareVersionNumbers(installedBuildVersion, latestBuildVersion)
+}
+
+// compareVersionNumbers compares all numeric components of version strings in order,
+// e.g. "1.2.10 (5432)" is newer than "1.2.9 (5400)"
+func compareVersionNumbers(installedVersion, latestVersion string) (int, bool) {
+
+	installedNumbers, installedOk := versionNumbers(installedVersion)
+	latestNumbers, latestOk := versionNumbers(latestVersion)
+
+	if !installedOk || !latestOk {
+		return 0, false
+	}
+
+	return slices.Compare(installedNumbers, latestNumbers), true
+}
+
+func versionNumbers(version string) ([]int64, bool) {
+
+	matches := versionNumbersRegexp.FindAllString(version, -1)
+	if len(matches) == 0 {
+		return nil, false
+	}
+
+	numbers := make([]int64, 0, len(matches))
+	for _, match := range matches {
+		number, err := strconv.ParseInt(match, 10, 64)
+		if err != nil {
+			return nil, false
+		}
+		numbers = append(numbers, number)
+	}
+
+	// trailing zero components don't change a version, e.g. "1.2" is the same as "1.2.0"
+	for len(numbers) > 1 && numbers[len(numbers)-1] == 0 {
+		numbers = numbers[:len(numbers)-1]
+	}
+
+	return numbers, true
+}
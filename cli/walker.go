@@ -1,9 +1,12 @@
 package cli
 
 import (
+	"io"
 	"io/fs"
+	"os"
 	"path/filepath"
 	"slices"
+	"time"
 )
 
 const (
@@ -48,3 +51,117 @@ func relWalkDir(absPath string) ([]string, error) {
 
 	return files, nil
 }
+
+// dirSize returns total size of all regular files in a directory
+func dirSize(absPath string) (int64, error) {
+
+	var size int64
+
+	if err := filepath.WalkDir(absPath, func(path string, d fs.DirEntry, err error) error {
+
+		if err != nil {
+			return err
+		}
+
+		if !d.Type().IsRegular() {
+			return nil
+		}
+
+		var info fs.FileInfo
+		if info, err = d.Info(); err != nil {
+			return err
+		}
+
+		size += info.Size()
+
+		return nil
+
+	}); err != nil {
+		return 0, err
+	}
+
+	return size, nil
+}
+
+// dirModTime returns the latest modification time of a dir or any of its contents
+func dirModTime(absPath string) (time.Time, error) {
+
+	var modTime time.Time
+
+	if err := filepath.WalkDir(absPath, func(path string, d fs.DirEntry, err error) error {
+
+		if err != nil {
+			return err
+		}
+
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+
+		if info.ModTime().After(modTime) {
+			modTime = info.ModTime()
+		}
+
+		return nil
+
+	}); err != nil {
+		return time.Time{}, err
+	}
+
+	return modTime, nil
+}
+
+// copyDir copies directory content preserving file modes and symlinks (e.g. in macOS app bundles)
+func copyDir(absSrcDir, absDstDir string) error {
+
+	return filepath.WalkDir(absSrcDir, func(path string, d fs.DirEntry, err error) error {
+
+		if err != nil {
+			return err
+		}
+
+		relPath, err := filepath.Rel(absSrcDir, path)
+		if err != nil {
+			return err
+		}
+
+		absDstPath := filepath.Join(absDstDir, relPath)
+
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+
+		switch {
+		case d.IsDir():
+			return os.MkdirAll(absDstPath, info.Mode().Perm())
+		case d.Type()&fs.ModeSymlink != 0:
+			var target string
+			if target, err = os.Readlink(path); err != nil {
+				return err
+			}
+			return os.Symlink(target, absDstPath)
+		default:
+			return copyFile(path, absDstPath, info.Mode().Perm())
+		}
+	})
+}
+
+func copyFile(absSrcPath, absDstPath string, perm fs.FileMode) error {
+
+	srcFile, err := os.Open(absSrcPath)
+	if err != nil {
+		return err
+	}
+	defer srcFile.Close()
+
+	dstFile, err := os.OpenFile(absDstPath, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, perm)
+	if err != nil {
+		return err
+	}
+	defer dstFile.Close()
+
+	_, err = io.Copy(dstFile, srcFile)
+	return err
+}
@@ -14,7 +14,7 @@ var FuncMap = map[string]func() []string{
 	"binaries-codes":        wine_integration.WineBinariesCodes,
 	"operating-systems":     vangogh_integration.OperatingSystemsCloValues,
 	"language-codes":        gog_integration.LanguageCodesCloValues,
-	"download-types":        vangogh_integration.DownloadTypesCloValues,
+	"download-types":        cli.DownloadTypes,
 	"proton-options":        wine_integration.AllProtonOptions,
 	"proton-runtimes":       wine_integration.AllProtonRuntimes,
 	"steam-proton-runtimes": wine_integration.AllSteamProtonRuntimes,
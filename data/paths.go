@@ -13,6 +13,14 @@ import (
 	"github.com/boggydigital/redux"
 )
 
+const (
+	relGogExtrasDir       = "gog-extras"
+	relValidationCacheDir = "validation-cache"
+	relSnapshotsDir       = "snapshots"
+	relSavesDir           = "saves"
+	relRunningSessionsDir = "running-sessions"
+)
+
 func GetTitleProperty(id string, rdx redux.Readable) (string, error) {
 	titleProperties := []string{
 		vangogh_integration.GogTitleProperty,
@@ -37,6 +45,9 @@ func OsLangCode(operatingSystem vangogh_integration.OperatingSystem, langCode st
 	return strings.Join([]string{operatingSystem.String(), langCode}, "-")
 }
 
+// GlobalLaunchOptionsKey is used instead of AppOsLangCode for launch options that apply to all products
+const GlobalLaunchOptionsKey = "global"
+
 func AppOsLangCode(id string, operatingSystem vangogh_integration.OperatingSystem, langCode string) string {
 	return strings.Join([]string{id, operatingSystem.String(), langCode}, "-")
 }
@@ -75,6 +86,74 @@ func AbsInventoryFilename(id, langCode string, operatingSystem vangogh_integrati
 	return filepath.Join(osLangInventoryDir, camino.Sanitize(title)+kevlar.JsonExt), nil
 }
 
+func AbsExtrasDir(id string, rdx redux.Readable) (string, error) {
+
+	title, err := GetTitleProperty(id, rdx)
+	if err != nil {
+		return "", err
+	}
+
+	return filepath.Join(camino.GetAbs(vangogh_integration.InstalledApps), relGogExtrasDir, camino.Sanitize(title)), nil
+}
+
+func AbsDlcInventoryFilename(id, dlcId, langCode string, operatingSystem vangogh_integration.OperatingSystem, rdx redux.Readable) (string, error) {
+
+	absInventoryFilename, err := AbsInventoryFilename(id, langCode, operatingSystem, rdx)
+	if err != nil {
+		return "", err
+	}
+
+	return strings.TrimSuffix(absInventoryFilename, kevlar.JsonExt) + "-dlc-" + camino.Sanitize(dlcId) + kevlar.JsonExt, nil
+}
+
+func AbsInventoryChecksumsFilename(id, langCode string, operatingSystem vangogh_integration.OperatingSystem, rdx redux.Readable) (string, error) {
+
+	absInventoryFilename, err := AbsInventoryFilename(id, langCode, operatingSystem, rdx)
+	if err != nil {
+		return "", err
+	}
+
+	return strings.TrimSuffix(absInventoryFilename, kevlar.JsonExt) + "-checksums" + kevlar.JsonExt, nil
+}
+
+func AbsSnapshotsDir(id string, rdx redux.Readable) (string, error) {
+
+	title, err := GetTitleProperty(id, rdx)
+	if err != nil {
+		return "", err
+	}
+
+	return filepath.Join(camino.GetAbs(vangogh_integration.Backups), relSnapshotsDir, camino.Sanitize(title)), nil
+}
+
+func AbsSavesDir(id string, rdx redux.Readable) (string, error) {
+
+	title, err := GetTitleProperty(id, rdx)
+	if err != nil {
+		return "", err
+	}
+
+	return filepath.Join(camino.GetAbs(vangogh_integration.Backups), relSavesDir, camino.Sanitize(title)), nil
+}
+
+func AbsProductLogsDir(id string, rdx redux.Readable) (string, error) {
+
+	title, err := GetTitleProperty(id, rdx)
+	if err != nil {
+		return "", err
+	}
+
+	return filepath.Join(camino.GetAbs(vangogh_integration.Logs), camino.Sanitize(title)), nil
+}
+
+func AbsRunningSessionsDir() string {
+	return filepath.Join(camino.GetAbs(vangogh_integration.Metadata), relRunningSessionsDir)
+}
+
+func AbsValidationCacheDir() string {
+	return filepath.Join(camino.GetAbs(vangogh_integration.Metadata), relValidationCacheDir)
+}
+
 func AbsSteamCmdBinPath(operatingSystem vangogh_integration.OperatingSystem) (string, error) {
 	switch operatingSystem {
 	case vangogh_integration.MacOS:
@@ -20,7 +20,16 @@ const (
 	LaunchOptionsArgProperty = "launch-options-arg"
 	LaunchOptionsEnvProperty = "launch-options-env"
 
+	LaunchOptionsPreHookProperty  = "launch-options-pre-hook"
+	LaunchOptionsPostHookProperty = "launch-options-post-hook"
+	LaunchOptionsWrapperProperty  = "launch-options-wrapper"
+
 	WineBinariesVersionsProperty = "wine-binaries-versions"
+
+	UpdateAvailableProperty = "update-available"
+	UpdatePolicyProperty    = "update-policy"
+
+	HistoryProperty = "history"
 )
 
 func VangoghProperties() []string {
@@ -68,7 +77,13 @@ func AllProperties() []string {
 			LaunchOptionsExeProperty,
 			LaunchOptionsArgProperty,
 			LaunchOptionsEnvProperty,
+			LaunchOptionsPreHookProperty,
+			LaunchOptionsPostHookProperty,
+			LaunchOptionsWrapperProperty,
 			WineBinariesVersionsProperty,
+			UpdateAvailableProperty,
+			UpdatePolicyProperty,
+			HistoryProperty,
 		}...)
 
 	return ap
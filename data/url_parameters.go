@@ -0,0 +1,34 @@
+package data
+
+const (
+	UrlExtrasParameter = "extras"
+	UrlAddParameter    = "add"
+	UrlRepairParameter = "repair"
+	UrlCheckParameter  = "check"
+	UrlAutoParameter   = "auto"
+
+	UrlSnapshotParameter       = "snapshot"
+	UrlSnapshotsLimitParameter = "snapshots-limit"
+	UrlAllowDowngradeParameter = "allow-downgrade"
+
+	UrlApplyParameter = "apply"
+	UrlFreeParameter  = "free"
+	UrlUnpinParameter = "unpin"
+	UrlAdoptParameter = "adopt"
+
+	UrlCompleteParameter  = "complete"
+	UrlKeepSavesParameter = "keep-saves"
+
+	UrlDryRunParameter = "dry-run"
+
+	UrlPreHookParameter  = "pre-hook"
+	UrlPostHookParameter = "post-hook"
+	UrlGlobalParameter   = "global"
+	UrlWrapperParameter  = "wrapper"
+
+	UrlProtonLogParameter = "proton-log"
+	UrlLastParameter      = "last"
+
+	UrlAttachParameter      = "attach"
+	UrlNewInstanceParameter = "new-instance"
+)
@@ -43,22 +43,33 @@ func main() {
 	clo.HandleFuncs(map[string]clo.Handler{
 		"backup-metadata":       cli.BackupMetadataHandler,
 		"connect":               cli.ConnectHandler,
+		"dlc":                   cli.DlcHandler,
 		"download":              cli.DownloadHandler,
+		"du":                    cli.DuHandler,
 		"fetch-data":            cli.FetchDataHandler,
 		"fix":                   cli.FixHandler,
+		"gc":                    cli.GcHandler,
+		"history":               cli.HistoryHandler,
 		"install":               cli.InstallHandler,
 		"launch-options":        cli.LaunchOptionsHandler,
 		"list":                  cli.ListHandler,
+		"logs":                  cli.LogsHandler,
 		"prefix":                cli.PrefixHandler,
 		"preset-launch-options": cli.PresetLaunchOptionsHandler,
+		"reconcile":             cli.ReconcileHandler,
 		"remove-downloads":      cli.RemoveDownloadsHandler,
 		"reveal":                cli.RevealHandler,
+		"rollback":              cli.RollbackHandler,
 		"run":                   cli.RunHandler,
+		"running":               cli.RunningHandler,
 		"setup-steamcmd":        cli.SetupSteamCmdHandler,
 		"setup-wine":            cli.SetupWineHandler,
 		"steam-shortcut":        cli.SteamShortcutHandler,
+		"stop":                  cli.StopHandler,
+		"suggest-cleanup":       cli.SuggestCleanupHandler,
 		"uninstall":             cli.UninstallHandler,
 		"update":                cli.UpdateHandler,
+		"update-policy":         cli.UpdatePolicyHandler,
 		"validate":              cli.ValidateHandler,
 		"version":               cli.VersionHandler,
 	})